@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	cognito "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+	"golang.org/x/xerrors"
+)
+
+// Authorizer adds the credentials of an auth provider to an outgoing request
+type Authorizer interface {
+	Authorize(ctx context.Context, req *http.Request) error
+}
+
+// AuthorizerFunc adapts a function to an Authorizer
+type AuthorizerFunc func(ctx context.Context, req *http.Request) error
+
+// Authorize calls f(ctx, req)
+func (f AuthorizerFunc) Authorize(ctx context.Context, req *http.Request) error {
+	return f(ctx, req)
+}
+
+// Authorize logs in with the cognito admin credentials and adds the id token as a bearer token,
+// requests only get the static Token, if any, when no cognito session was configured
+func (a *ClientAuthorization) Authorize(ctx context.Context, req *http.Request) error {
+	if a.CognitoIdentityProvider == nil {
+		if a.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+a.Token)
+		}
+
+		return nil
+	}
+
+	// Login with cognito admin credentials
+	// If the credentials of the session were revoked before their expiry, such as rotated STS credentials,
+	// expire them to login again with refreshed ones, whatever the retries of the session
+	// Exit on error
+	login, err := a.adminLogin(ctx)
+	if request.IsErrorExpiredCreds(err) && a.CognitoIdentityProvider.Config.Credentials != nil {
+		a.CognitoIdentityProvider.Config.Credentials.Expire()
+		login, err = a.adminLogin(ctx)
+	}
+	if err != nil {
+		return xerrors.Errorf("failed to login : %w", err)
+	}
+
+	// If authentication result is successful and id token can be parsed
+	// Add in authentication header
+	if login != nil && login.AuthenticationResult != nil && login.AuthenticationResult.IdToken != nil {
+		req.Header.Add("Authorization", "Bearer "+*login.AuthenticationResult.IdToken)
+	}
+
+	return nil
+}
+
+// adminLogin logs in with the cognito admin credentials, signing the request with the credentials of the session,
+// to the user pool of the context if any
+func (a *ClientAuthorization) adminLogin(ctx context.Context) (*cognito.AdminInitiateAuthOutput, error) {
+	pool := CognitoPool{UserPoolID: a.UserPoolID, ClientID: a.ClientID, Username: a.Username, Password: a.Password}
+	if tenant, ok := cognitoPool(ctx); ok {
+		pool = pool.override(tenant)
+	}
+
+	return a.CognitoIdentityProvider.AdminInitiateAuthWithContext(ctx, &cognito.AdminInitiateAuthInput{
+		AuthFlow:   aws.String("ADMIN_USER_PASSWORD_AUTH"),
+		ClientId:   aws.String(pool.ClientID),
+		UserPoolId: aws.String(pool.UserPoolID),
+		AuthParameters: map[string]*string{
+			"USERNAME": aws.String(pool.Username),
+			"PASSWORD": aws.String(pool.Password),
+		},
+	})
+}
+
+// CognitoPool is the cognito user pool, app client and admin credentials a request logs in with
+type CognitoPool struct {
+	UserPoolID string
+	ClientID   string
+	Username   string
+	Password   string
+}
+
+// override returns p with the non-empty fields of tenant
+func (p CognitoPool) override(tenant CognitoPool) CognitoPool {
+	if tenant.UserPoolID != "" {
+		p.UserPoolID = tenant.UserPoolID
+	}
+	if tenant.ClientID != "" {
+		p.ClientID = tenant.ClientID
+	}
+	if tenant.Username != "" {
+		p.Username = tenant.Username
+	}
+	if tenant.Password != "" {
+		p.Password = tenant.Password
+	}
+
+	return p
+}
+
+type cognitoPoolKey struct{}
+
+// WithCognitoPool returns a context whose requests authorized by the cognito session of the client log in to pool,
+// the user pool of the tenant of the request in multi-tenant setups. The empty fields of pool keep the ones of the
+// ClientAuthorizationOptions. The cached responses are not shared between pools.
+func WithCognitoPool(ctx context.Context, pool CognitoPool) context.Context {
+	return context.WithValue(ctx, cognitoPoolKey{}, pool)
+}
+
+func cognitoPool(ctx context.Context) (CognitoPool, bool) {
+	pool, ok := ctx.Value(cognitoPoolKey{}).(CognitoPool)
+
+	return pool, ok
+}
+
+type bearerTokenKey struct{}
+
+// WithBearerToken returns a context whose requests send token verbatim as a bearer token instead of being
+// authorized by the client, to forward the JWT of the end user of an incoming request for instance
+func WithBearerToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, bearerTokenKey{}, token)
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(bearerTokenKey{}).(string)
+
+	return token, ok
+}
+
+// AuthenticateUnlessLocal is a Client.ShouldAuthenticate predicate skipping the authorization of
+// plaintext requests to a loopback host, such as a development server on http://localhost
+func AuthenticateUnlessLocal(req *http.Request) bool {
+	if req.URL.Scheme != "http" {
+		return true
+	}
+
+	host := req.URL.Hostname()
+	if host == "localhost" {
+		return false
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip == nil || !ip.IsLoopback()
+}
+
+// authorizer returns the auth provider mapped to the operation, the client authorization by default
+func (c *Client) authorizer(operationName string) (Authorizer, error) {
+	name, ok := c.OperationAuthorizers[operationName]
+	if !ok {
+		return &c.Authorization, nil
+	}
+
+	authorizer, ok := c.Authorizers[name]
+	if !ok {
+		return nil, xerrors.Errorf("unknown authorizer %q for operation %s", name, operationName)
+	}
+
+	return authorizer, nil
+}
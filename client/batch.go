@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of requests a batch sends at a time when Client.BatchConcurrency is not set
+const DefaultBatchConcurrency = 4
+
+type batchConcurrencyKey struct{}
+
+// WithBatchConcurrency returns a context overriding Client.BatchConcurrency for the batches called with it
+func WithBatchConcurrency(ctx context.Context, concurrency int) context.Context {
+	return context.WithValue(ctx, batchConcurrencyKey{}, concurrency)
+}
+
+// Batch calls fn for each index in [0, n), running at most BatchConcurrency calls at a time,
+// and returns once every call returned. The calls are still made after ctx is done, to report its error.
+func (c *Client) Batch(ctx context.Context, n int, fn func(ctx context.Context, i int)) {
+	concurrency := c.BatchConcurrency
+	if override, ok := ctx.Value(batchConcurrencyKey{}).(int); ok && override > 0 {
+		concurrency = override
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	if concurrency > n {
+		concurrency = n
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for w := 0; w < concurrency; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(ctx, i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
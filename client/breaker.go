@@ -0,0 +1,126 @@
+package client
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// CircuitBreaker guards the calls to the graphql endpoint.
+// It is satisfied by github.com/sony/gobreaker's *CircuitBreaker.
+type CircuitBreaker interface {
+	// Execute runs req when the circuit is closed, and returns an error without calling it when open
+	Execute(req func() (interface{}, error)) (interface{}, error)
+}
+
+// ErrCircuitOpen is returned while the circuit breaker rejects requests, it wraps the errors of the breakers
+// rejecting a request without sending it
+var ErrCircuitOpen = xerrors.New("circuit breaker is open")
+
+// errServerFailure reports a 5xx response to the circuit breaker
+var errServerFailure = xerrors.New("server failure")
+
+// doHTTP sends req through the circuit breaker, counting transport errors and 5xx responses as failures
+func (c *Client) doHTTP(req *http.Request) (*http.Response, error) {
+	if c.CircuitBreaker == nil {
+		return c.Client.Do(req)
+	}
+
+	sent := false
+	result, err := c.CircuitBreaker.Execute(func() (interface{}, error) {
+		sent = true
+		resp, err := c.Client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return resp, errServerFailure
+		}
+
+		return resp, nil
+	})
+
+	// 5xx responses are still returned to parse their body
+	if resp, ok := result.(*http.Response); ok && resp != nil {
+		return resp, nil
+	}
+
+	// The rejections of the other breakers, such as gobreaker's ErrOpenState and ErrTooManyRequests, are ErrCircuitOpen
+	if err != nil && !sent && !xerrors.Is(err, ErrCircuitOpen) {
+		return nil, xerrors.Errorf("%s: %w", err, ErrCircuitOpen)
+	}
+
+	return nil, err
+}
+
+// consecutiveFailuresBreaker opens after a number of consecutive failures,
+// then lets a single trial request through once the cooldown has elapsed
+type consecutiveFailuresBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewCircuitBreaker returns a CircuitBreaker which fails fast with ErrCircuitOpen for cooldown
+// after threshold consecutive failures
+func NewCircuitBreaker(threshold int, cooldown time.Duration) CircuitBreaker {
+	return &consecutiveFailuresBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+func (b *consecutiveFailuresBreaker) Execute(req func() (interface{}, error)) (interface{}, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+
+	result, err := req()
+	b.record(err == nil)
+
+	return result, err
+}
+
+func (b *consecutiveFailuresBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+
+	if b.trial || b.now().Sub(b.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+
+	// half-open, let a single request decide whether to close the circuit
+	b.trial = true
+
+	return nil
+}
+
+func (b *consecutiveFailuresBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if success {
+		b.failures = 0
+
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
@@ -0,0 +1,292 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"golang.org/x/xerrors"
+)
+
+// Cache stores the bodies of query responses, implement it over Redis for instance to share them between processes.
+// Share a cache only between clients authorized to see the same data.
+type Cache interface {
+	// Get returns the body stored for key, ok is false on a miss
+	Get(ctx context.Context, key string) (body []byte, ok bool, err error)
+	// Set stores body for key during ttl
+	Set(ctx context.Context, key string, body []byte, ttl time.Duration) error
+}
+
+// maxMemoryCacheEntries is the number of responses held by the Cache of NewMemoryCache
+const maxMemoryCacheEntries = 1024
+
+// memoryCache is an in-process Cache holding up to size entries, the expired entries are removed when read
+// or when the cache is full
+type memoryCache struct {
+	now     func() time.Time
+	size    int
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	body      []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCache returns a Cache holding up to 1024 responses in memory
+func NewMemoryCache() Cache {
+	return NewMemoryCacheSize(maxMemoryCacheEntries)
+}
+
+// NewMemoryCacheSize returns a Cache holding up to size responses in memory, 1024 like NewMemoryCache when size is
+// not positive. When it is full, the expired responses are removed, then the one expiring first.
+func NewMemoryCacheSize(size int) Cache {
+	if size <= 0 {
+		size = maxMemoryCacheEntries
+	}
+
+	return &memoryCache{
+		now:     time.Now,
+		size:    size,
+		entries: make(map[string]memoryCacheEntry),
+	}
+}
+
+func (m *memoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if !m.now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+
+		return nil, false, nil
+	}
+
+	return entry.body, true, nil
+}
+
+func (m *memoryCache) Set(_ context.Context, key string, body []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if _, ok := m.entries[key]; !ok && len(m.entries) >= m.size {
+		m.evict(now)
+	}
+	m.entries[key] = memoryCacheEntry{body: body, expiresAt: now.Add(ttl)}
+
+	return nil
+}
+
+// evict removes the expired entries, or the one expiring first when none is
+func (m *memoryCache) evict(now time.Time) {
+	var first string
+	var firstExpiresAt time.Time
+	for key, entry := range m.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(m.entries, key)
+
+			continue
+		}
+		if firstExpiresAt.IsZero() || entry.expiresAt.Before(firstExpiresAt) {
+			first, firstExpiresAt = key, entry.expiresAt
+		}
+	}
+
+	if len(m.entries) >= m.size && !firstExpiresAt.IsZero() {
+		delete(m.entries, first)
+	}
+}
+
+// operationTypes caches the types of the operations by requestBodyKey
+var operationTypes sync.Map
+
+// operationType returns the type of the operation operationName of the document, empty when it is not found
+func operationType(operationName, query string) ast.Operation {
+	key := requestBodyKey{operationName: operationName, query: query}
+	if cached, ok := operationTypes.Load(key); ok {
+		return cached.(ast.Operation)
+	}
+
+	document, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return ""
+	}
+
+	var operation *ast.OperationDefinition
+	if len(document.Operations) == 1 {
+		operation = document.Operations[0]
+	} else {
+		operation = document.Operations.ForName(operationName)
+	}
+
+	var result ast.Operation
+	if operation != nil {
+		result = operation.Operation
+	}
+	operationTypes.Store(key, result)
+
+	return result
+}
+
+// isQuery reports whether operationName is a query of the document, so its response may be cached
+func isQuery(operationName, query string) bool {
+	return operationType(operationName, query) == ast.Query
+}
+
+// cacheKey identifies the response of an operation called with vars and the per-call httpRequestOptions on the
+// endpoint of the client, by the bearer token and cognito pool of the context too so the users whose token is
+// forwarded and the tenants do not share responses
+func (c *Client) cacheKey(ctx context.Context, operationName, query string, vars map[string]interface{}, httpRequestOptions []HTTPRequestOption) (string, error) {
+	variables, err := json.Marshal(vars)
+	if err != nil {
+		return "", err
+	}
+
+	options, err := c.requestOptionsKey(httpRequestOptions)
+	if err != nil {
+		return "", err
+	}
+
+	token, _ := bearerToken(ctx)
+	pool, _ := cognitoPool(ctx)
+	hash := sha256.New()
+	for _, part := range [][]byte{[]byte(c.BaseURL), []byte(token), []byte(pool.UserPoolID), []byte(pool.ClientID), []byte(pool.Username), options, []byte(operationName), []byte(query), variables} {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// requestOptionsKey returns the URL and headers the per-call httpRequestOptions set on a request, so that the calls
+// authorized by an option, such as a per-user Authorization or tenant header, do not share responses
+func (c *Client) requestOptionsKey(httpRequestOptions []HTTPRequestOption) ([]byte, error) {
+	if len(httpRequestOptions) == 0 {
+		return nil, nil
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.BaseURL, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	for _, httpRequestOption := range httpRequestOptions {
+		httpRequestOption(req)
+	}
+
+	// Header.Write sorts the headers by key
+	var key bytes.Buffer
+	key.WriteString(req.URL.String())
+	key.WriteByte(0)
+	if err := req.Header.Write(&key); err != nil {
+		return nil, err
+	}
+
+	return key.Bytes(), nil
+}
+
+// cacheControl is the cache hint of the cacheControl response extension
+type cacheControl struct {
+	Extensions struct {
+		CacheControl *struct {
+			MaxAge *int `json:"maxAge"`
+		} `json:"cacheControl"`
+	} `json:"extensions"`
+	Errors json.RawMessage `json:"errors"`
+}
+
+// cacheTTL returns how long to cache a response body, the maxAge in seconds of its cacheControl extension
+// or CacheTTL when the server sends none. It is 0, not caching the response, when the body holds errors.
+func (c *Client) cacheTTL(body []byte) time.Duration {
+	var hint cacheControl
+	if err := json.Unmarshal(body, &hint); err != nil || len(hint.Errors) > 0 {
+		return 0
+	}
+
+	if hint.Extensions.CacheControl != nil && hint.Extensions.CacheControl.MaxAge != nil {
+		return time.Duration(*hint.Extensions.CacheControl.MaxAge) * time.Second
+	}
+
+	return c.CacheTTL
+}
+
+// ErrNotCacheable is returned by Prefetch when the client has no Cache or the operation is not a query
+var ErrNotCacheable = xerrors.New("operation not cacheable")
+
+// Prefetch sends the query and stores its response in Cache without returning it, to warm the cache at startup
+// for instance. The response stored is not read, Prefetch refreshes it. respData only checks the response decodes.
+func (c *Client) Prefetch(ctx context.Context, operationName, query string, respData interface{}, vars map[string]interface{}, httpRequestOptions ...HTTPRequestOption) error {
+	if c.Cache == nil || !isQuery(operationName, query) {
+		return xerrors.Errorf("%s: %w", operationName, ErrNotCacheable)
+	}
+
+	key, err := c.cacheKey(ctx, operationName, query, vars, httpRequestOptions)
+	if err != nil {
+		return xerrors.Errorf("%s: cache key: %w", operationName, err)
+	}
+
+	body, statusCode, header, err := c.conditionalDo(ctx, key, operationName, query, vars, httpRequestOptions)
+	if err != nil {
+		return err
+	}
+
+	if ttl := c.cacheTTL(body); statusCode == http.StatusOK && ttl > 0 {
+		if err := c.Cache.Set(ctx, key, body, ttl); err != nil {
+			return xerrors.Errorf("%s: store response: %w", operationName, err)
+		}
+	}
+
+	return c.parse(body, statusCode, header, respData)
+}
+
+// cachedDo is do consulting Cache for queries, it stores the successful responses.
+// Mutations are coalesced instead, and the queries sent as GET requests revalidated with their ETag.
+func (c *Client) cachedDo(ctx context.Context, operationName, query string, vars map[string]interface{}, httpRequestOptions []HTTPRequestOption) ([]byte, int, http.Header, error) {
+	if operationType(operationName, query) == ast.Mutation {
+		return c.coalescedDo(ctx, operationName, query, vars, httpRequestOptions)
+	}
+
+	if (c.Cache == nil && !c.GetQueries) || !isQuery(operationName, query) {
+		return c.do(ctx, operationName, query, vars, httpRequestOptions)
+	}
+
+	if err := c.checkAllowed(operationName); err != nil {
+		return nil, 0, nil, err
+	}
+
+	key, err := c.cacheKey(ctx, operationName, query, vars, httpRequestOptions)
+	if err != nil {
+		return c.do(ctx, operationName, query, vars, httpRequestOptions)
+	}
+
+	// the cache is an optimization, its failures fall back to the endpoint
+	if c.Cache != nil {
+		if body, ok, err := c.Cache.Get(ctx, key); err == nil && ok {
+			return body, http.StatusOK, nil, nil
+		}
+	}
+
+	body, statusCode, header, err := c.conditionalDo(ctx, key, operationName, query, vars, httpRequestOptions)
+	if err != nil || statusCode != http.StatusOK {
+		return body, statusCode, header, err
+	}
+
+	if ttl := c.cacheTTL(body); c.Cache != nil && ttl > 0 {
+		_ = c.Cache.Set(ctx, key, body, ttl)
+	}
+
+	return body, statusCode, header, nil
+}
@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"mime"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
 	session "github.com/aws/aws-sdk-go/aws/session"
 	cognito "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
 	"github.com/perchcredit/gqlgenc/graphqljson"
@@ -20,14 +23,115 @@ import (
 // HTTPRequestOption represents the options applicable to the http client
 type HTTPRequestOption func(req *http.Request)
 
+// WithHeader returns an HTTPRequestOption setting the header key to value
+func WithHeader(key, value string) HTTPRequestOption {
+	return func(req *http.Request) {
+		req.Header.Set(key, value)
+	}
+}
+
 // ----- Client ---------------------------------------------------
 
+// Doer sends http requests, it is satisfied by *http.Client and lets tests and middlewares replace it
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
 // Client is the http client wrapper
 type Client struct {
 	BaseURL            string
-	Client             *http.Client
+	Client             Doer
 	HTTPRequestOptions []HTTPRequestOption
 	Authorization      ClientAuthorization
+	// TimeLayout is the layout used to encode time.Time variables in UTC, RFC3339 with nanoseconds when empty
+	TimeLayout string
+	// AllowedOperations restricts the operations the client sends, every operation is allowed when nil
+	AllowedOperations map[string]bool
+	// CircuitBreaker fails requests fast while the endpoint is failing, disabled when nil
+	CircuitBreaker CircuitBreaker
+	// Authorizers are the named auth providers available to OperationAuthorizers
+	Authorizers map[string]Authorizer
+	// OperationAuthorizers maps operation names to the name of their auth provider in Authorizers,
+	// operations missing from it use Authorization
+	OperationAuthorizers map[string]string
+	// ShouldAuthenticate reports whether to authorize a request, every request but introspection is authorized when nil
+	ShouldAuthenticate func(req *http.Request) bool
+	// OnRequest is called with the redacted variables before sending a request, to log it for instance
+	OnRequest func(operationName string, vars map[string]interface{})
+	// RedactVariables masks the sensitive variables passed to OnRequest, DefaultRedactVariables when nil
+	RedactVariables func(vars map[string]interface{}) map[string]interface{}
+	// OnResponse is called after each call with the JSON form of the decoded response, its RedactResponseFields
+	// masked, and the error of the call, to log or trace it. data is nil when the call failed.
+	OnResponse func(operationName string, data map[string]interface{}, err error)
+	// RedactResponseFields are the paths of the response fields masked in the data passed to OnResponse, by
+	// operation name, "*" applying to every operation. A path is the response keys joined by dots, such as
+	// "users.ssn", the lists being traversed. The responses returned to the caller are not masked.
+	RedactResponseFields map[string][]string
+	// Accept is the Accept header of the requests, DefaultAccept when empty
+	Accept string
+	// OnUploadProgress is called while sending the files of a request holding uploads,
+	// total is -1 when the size of an upload is unknown
+	OnUploadProgress func(bytesSent, total int64)
+	// DisallowUnknownFields fails decoding responses holding fields missing from the response struct
+	DisallowUnknownFields bool
+	// UseNumber decodes the numbers of the interface{} response fields, such as the ones of custom scalars
+	// mapped to interface{}, as json.Number instead of float64 to keep their precision
+	UseNumber bool
+	// BatchConcurrency is the number of requests a batch sends at a time, DefaultBatchConcurrency when not set
+	BatchConcurrency int
+	// Cache stores the responses of queries during the maxAge of their cacheControl extension,
+	// mutations are never cached. Disabled when nil.
+	Cache Cache
+	// CacheTTL is how long to cache the responses without cacheControl extension, they are not cached when 0
+	CacheTTL time.Duration
+	// OnServerTiming is called with the metrics of the Server-Timing header of the responses holding one
+	OnServerTiming func(operationName string, timings []ServerTiming)
+	// IdempotencyKeyHeader is the header of the random UUID identifying each mutation call, not sent when empty
+	IdempotencyKeyHeader string
+	// MutationCoalesceWindow is how long identical mutation calls return the result of the first one instead of
+	// being sent, to absorb double clicks for instance. Only enable it when every mutation is idempotent,
+	// disabled when 0.
+	MutationCoalesceWindow time.Duration
+	// GetQueries sends the queries without uploads as GET requests, which CDNs and HTTP caches can serve,
+	// and revalidates their responses carrying an ETag with If-None-Match
+	GetQueries bool
+	// Transport sends the operations instead of the HTTP client when set, the HTTP specific options
+	// such as the request options, authorization and uploads are then not applied
+	Transport Transport
+	// MaxConcurrentRequests caps the requests in flight, the others wait for one to complete, unlimited when 0.
+	// Clients created by NewClient enforce it, sharing the cap with their clones.
+	MaxConcurrentRequests int
+	// MaxConcurrentRequestsFailFast fails the requests over MaxConcurrentRequests with ErrTooManyRequests instead of waiting
+	MaxConcurrentRequestsFailFast bool
+	// MaxRetries is how many times a failed attempt is retried, when sending the request failed or the server
+	// answered 429 or a 5xx status. Mutations are only retried with an IdempotencyKeyHeader, not retried when 0.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled at each retry, DefaultRetryBackoff when not set.
+	// The Retry-After header of the responses takes precedence.
+	RetryBackoff time.Duration
+	// RetryBudget caps the retries to a ratio of the calls, shared by the clones of the client.
+	// NewClient creates one of DefaultRetryBudgetRatio and DefaultRetryBudgetBurst when MaxRetries is set.
+	RetryBudget *RetryBudget
+	// RequestTimeout bounds each attempt of a call, retries included, no timeout when 0
+	RequestTimeout time.Duration
+	// OperationNameHeader is the header sending the operation name, for gateways routing or logging the requests
+	// by operation without reading their body, not sent when empty
+	OperationNameHeader string
+	// SendEmptyVariables sends "variables": {} for the HTTP requests of the operations without variables,
+	// for the servers requiring the field, instead of omitting it
+	SendEmptyVariables bool
+	// ErrorPrecedence orders the errors of the ErrorResponses holding both a NetworkError and GqlErrors,
+	// NetworkErrorFirst by default
+	ErrorPrecedence ErrorPrecedence
+
+	// marshalled bodies of the operations sent without variables
+	requestBodies *sync.Map
+	// mutation calls joined by identical calls during MutationCoalesceWindow
+	mutationCalls *mutationCalls
+	// responses of the queries sent as GET requests revalidated with their ETag
+	etags *etagStore
+	// slots of the MaxConcurrentRequests requests in flight
+	requestSlots chan struct{}
 }
 
 type ClientAuthorization struct {
@@ -36,8 +140,19 @@ type ClientAuthorization struct {
 	UserPoolID              string
 	Username                string
 	Password                string
+	// Token is the static bearer token sent when no cognito session is configured
+	Token string
 }
 
+// DefaultAccept accepts the media type of the GraphQL over HTTP specification and the legacy JSON one
+const DefaultAccept = "application/graphql-response+json, application/json; charset=utf-8"
+
+// ErrOperationNotAllowed is returned when sending an operation missing from Client.AllowedOperations
+var ErrOperationNotAllowed = xerrors.New("operation not allowed")
+
+// ErrUnknownOperation is returned by the generated Execute method for an operation missing from the generated client
+var ErrUnknownOperation = xerrors.New("unknown operation")
+
 // Request represents an outgoing GraphQL request
 type Request struct {
 	Query         string                 `json:"query"`
@@ -48,23 +163,61 @@ type Request struct {
 // ----- Client Initialization Options ----------------------------
 
 type ClientOptions struct {
-	HTTPClient           *http.Client
-	HTTPRequestOptions   []HTTPRequestOption
-	BaseURL              string
-	AuthorizationOptions ClientAuthorizationOptions
+	HTTPClient                    Doer
+	HTTPRequestOptions            []HTTPRequestOption
+	BaseURL                       string
+	AuthorizationOptions          ClientAuthorizationOptions
+	TimeLayout                    string
+	AllowedOperations             map[string]bool
+	CircuitBreaker                CircuitBreaker
+	Authorizers                   map[string]Authorizer
+	OperationAuthorizers          map[string]string
+	ShouldAuthenticate            func(req *http.Request) bool
+	OnRequest                     func(operationName string, vars map[string]interface{})
+	RedactVariables               func(vars map[string]interface{}) map[string]interface{}
+	OnResponse                    func(operationName string, data map[string]interface{}, err error)
+	RedactResponseFields          map[string][]string
+	OnUploadProgress              func(bytesSent, total int64)
+	Accept                        string
+	DisallowUnknownFields         bool
+	UseNumber                     bool
+	BatchConcurrency              int
+	Cache                         Cache
+	CacheTTL                      time.Duration
+	IdempotencyKeyHeader          string
+	OnServerTiming                func(operationName string, timings []ServerTiming)
+	MutationCoalesceWindow        time.Duration
+	GetQueries                    bool
+	Transport                     Transport
+	MaxConcurrentRequests         int
+	MaxConcurrentRequestsFailFast bool
+	MaxRetries                    int
+	RetryBackoff                  time.Duration
+	RetryBudget                   *RetryBudget
+	RequestTimeout                time.Duration
+	RedirectPolicy                RedirectPolicy
+	OperationNameHeader           string
+	SendEmptyVariables            bool
+	ErrorPrecedence               ErrorPrecedence
 }
 
 type ClientAuthorizationOptions struct {
+	// Session signs the cognito logins, its credentials are shared by the clones of the client and refreshed by
+	// the SDK once expired, such as the IRSA and STS credentials of session.NewSession. The logins failing with
+	// expired credentials expire them and are sent again. Static credentials are never refreshed.
 	Session    *session.Session
 	ClientID   string
 	UserPoolID string
 	Username   string
 	Password   string
+	// Token is the static bearer token sent when no cognito session is configured
+	Token string
 }
 
 // ----- Client Constructor ----------------------------------------
 
-// NewClient creates a new http client wrapper
+// NewClient creates a new http client wrapper, sending the requests with NewHTTPClient and the RedirectPolicy
+// of the options when no HTTPClient is set
 func NewClient(options ClientOptions) *Client {
 
 	// Create base client authorization
@@ -73,6 +226,7 @@ func NewClient(options ClientOptions) *Client {
 		ClientID:   options.AuthorizationOptions.ClientID,
 		Username:   options.AuthorizationOptions.Username,
 		Password:   options.AuthorizationOptions.Password,
+		Token:      options.AuthorizationOptions.Token,
 	}
 
 	// If authorization options is provided
@@ -81,64 +235,133 @@ func NewClient(options ClientOptions) *Client {
 		authorization.CognitoIdentityProvider = cognito.New(options.AuthorizationOptions.Session)
 	}
 
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = NewHTTPClient(0, options.RedirectPolicy)
+	}
+
+	var requestSlots chan struct{}
+	if options.MaxConcurrentRequests > 0 {
+		requestSlots = make(chan struct{}, options.MaxConcurrentRequests)
+	}
+
+	retryBudget := options.RetryBudget
+	if retryBudget == nil && options.MaxRetries > 0 {
+		retryBudget = NewRetryBudget(DefaultRetryBudgetRatio, DefaultRetryBudgetBurst)
+	}
+
 	return &Client{
-		Client:             options.HTTPClient,
-		HTTPRequestOptions: options.HTTPRequestOptions,
-		BaseURL:            options.BaseURL,
-		Authorization:      authorization,
+		Client:                        httpClient,
+		HTTPRequestOptions:            options.HTTPRequestOptions,
+		BaseURL:                       options.BaseURL,
+		Authorization:                 authorization,
+		TimeLayout:                    options.TimeLayout,
+		AllowedOperations:             options.AllowedOperations,
+		CircuitBreaker:                options.CircuitBreaker,
+		Authorizers:                   options.Authorizers,
+		OperationAuthorizers:          options.OperationAuthorizers,
+		ShouldAuthenticate:            options.ShouldAuthenticate,
+		OnRequest:                     options.OnRequest,
+		RedactVariables:               options.RedactVariables,
+		OnResponse:                    options.OnResponse,
+		RedactResponseFields:          options.RedactResponseFields,
+		OnUploadProgress:              options.OnUploadProgress,
+		Accept:                        options.Accept,
+		DisallowUnknownFields:         options.DisallowUnknownFields,
+		UseNumber:                     options.UseNumber,
+		BatchConcurrency:              options.BatchConcurrency,
+		Cache:                         options.Cache,
+		CacheTTL:                      options.CacheTTL,
+		IdempotencyKeyHeader:          options.IdempotencyKeyHeader,
+		OnServerTiming:                options.OnServerTiming,
+		MutationCoalesceWindow:        options.MutationCoalesceWindow,
+		GetQueries:                    options.GetQueries,
+		Transport:                     options.Transport,
+		MaxConcurrentRequests:         options.MaxConcurrentRequests,
+		MaxConcurrentRequestsFailFast: options.MaxConcurrentRequestsFailFast,
+		MaxRetries:                    options.MaxRetries,
+		RetryBackoff:                  options.RetryBackoff,
+		RetryBudget:                   retryBudget,
+		RequestTimeout:                options.RequestTimeout,
+		OperationNameHeader:           options.OperationNameHeader,
+		SendEmptyVariables:            options.SendEmptyVariables,
+		ErrorPrecedence:               options.ErrorPrecedence,
+		requestBodies:                 &sync.Map{},
+		mutationCalls:                 &mutationCalls{calls: make(map[string]*mutationCall)},
+		etags:                         newETagStore(),
+		requestSlots:                  requestSlots,
 	}
 }
 
-func (c *Client) newRequest(ctx context.Context, operationName, query string, vars map[string]interface{}, httpRequestOptions []HTTPRequestOption) (*http.Request, error) {
+// Clone returns a copy of the client to customize without changing c, the option slice and maps
+// are copied while the http client, the authorization providers, the circuit breaker and the retry budget are shared.
+// Mutation calls of the copy do not join the ones of c, nor revalidate its responses.
+func (c *Client) Clone() *Client {
+	clone := *c
+	clone.HTTPRequestOptions = append([]HTTPRequestOption(nil), c.HTTPRequestOptions...)
+	clone.AllowedOperations = copyMap(c.AllowedOperations)
+	clone.Authorizers = copyMap(c.Authorizers)
+	clone.OperationAuthorizers = copyMap(c.OperationAuthorizers)
+	if c.mutationCalls != nil {
+		clone.mutationCalls = &mutationCalls{calls: make(map[string]*mutationCall)}
+	}
+	if c.etags != nil {
+		clone.etags = newETagStore()
+	}
 
-	// Create request object
-	// Fill query
-	// Fill variables
-	r := &Request{
-		Query:     query,
-		Variables: vars,
+	return &clone
+}
+
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
 	}
 
-	// Marshal request body
-	// Exit on error
-	requestBody, err := json.Marshal(r)
-	if err != nil {
-		return nil, xerrors.Errorf("encode: %w", err)
+	copied := make(map[K]V, len(m))
+	for k, v := range m {
+		copied[k] = v
 	}
 
-	// Create new request
+	return copied
+}
+
+func (c *Client) newRequest(ctx context.Context, operationName, query string, vars map[string]interface{}, httpRequestOptions []HTTPRequestOption) (*http.Request, error) {
+
+	// Encode time variables with the time layout and omit the unset Optionals
+	vars = c.formatVariables(vars)
+
+	// Create the request, a GET request for queries when GetQueries is set
 	// Exit on error
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL, bytes.NewBuffer(requestBody))
+	req, err := c.httpRequest(ctx, operationName, query, vars)
 	if err != nil {
-		return nil, xerrors.Errorf("create request struct failed: %w", err)
+		return nil, err
 	}
 
-	// If query is not introspection query
+	// If the context carries a bearer token
+	// Forward it instead of authorizing the request
+	// Else if query is not introspection query and the request should be authenticated
 	// Add appropriate authorization headers
-	if query != introspection.Introspection {
+	if token, ok := bearerToken(ctx); ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if !introspection.IsIntrospection(query) && (c.ShouldAuthenticate == nil || c.ShouldAuthenticate(req)) {
 
-		// Login with cognito admin credentials
+		// Pick the auth provider of the operation
 		// Exit on error
-		login, err := c.Authorization.CognitoIdentityProvider.AdminInitiateAuth(&cognito.AdminInitiateAuthInput{
-			AuthFlow:   aws.String("ADMIN_USER_PASSWORD_AUTH"),
-			ClientId:   &c.Authorization.ClientID,
-			UserPoolId: &c.Authorization.UserPoolID,
-			AuthParameters: map[string]*string{
-				"USERNAME": aws.String(c.Authorization.Username),
-				"PASSWORD": aws.String(c.Authorization.Password),
-			},
-		})
+		authorizer, err := c.authorizer(operationName)
 		if err != nil {
-			return nil, xerrors.Errorf("failed to login : %w", err)
+			return nil, err
 		}
-
-		// If authentication result is successful and id token can be parsed
-		// Add in authentication header
-		if login != nil && login.AuthenticationResult != nil && login.AuthenticationResult.IdToken != nil {
-			req.Header.Add("Authorization", "Bearer "+*login.AuthenticationResult.IdToken)
+		if err := authorizer.Authorize(ctx, req); err != nil {
+			return nil, err
 		}
 	}
 
+	// If an operation name header is configured
+	// Send the operation name in it
+	if c.OperationNameHeader != "" && operationName != "" {
+		req.Header.Set(c.OperationNameHeader, operationName)
+	}
+
 	// Add HTTP Options
 	for _, httpRequestOption := range c.HTTPRequestOptions {
 		httpRequestOption(req)
@@ -150,6 +373,105 @@ func (c *Client) newRequest(ctx context.Context, operationName, query string, va
 	return req, nil
 }
 
+// httpRequest creates the request sending the operation, a GET request for the queries without uploads
+// when GetQueries is set and a POST request otherwise
+func (c *Client) httpRequest(ctx context.Context, operationName, query string, vars map[string]interface{}) (*http.Request, error) {
+	uploads := collectUploads(vars)
+	if c.GetQueries && len(uploads) == 0 && isQuery(operationName, query) {
+		return c.getRequest(ctx, operationName, query, vars)
+	}
+
+	// Marshal request body
+	// Exit on error
+	requestBody, err := c.requestBody(operationName, query, vars)
+	if err != nil {
+		return nil, xerrors.Errorf("encode: %w", err)
+	}
+
+	return c.postRequest(ctx, requestBody, uploads)
+}
+
+// postRequest creates a POST request sending requestBody, in a multipart body with the uploads when there are some
+func (c *Client) postRequest(ctx context.Context, requestBody []byte, uploads []fileUpload) (*http.Request, error) {
+	// Create new request
+	// Exit on error
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, xerrors.Errorf("create request struct failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	// If variables hold uploads
+	// Send them with the operations in a multipart body
+	if len(uploads) > 0 {
+		body, contentType, err := c.multipartBody(requestBody, uploads)
+		if err != nil {
+			return nil, xerrors.Errorf("encode uploads: %w", err)
+		}
+
+		req.Body = body
+		req.GetBody = nil
+		req.ContentLength = 0
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return req, nil
+}
+
+// requestBodyKey identifies the marshalled body of a static operation
+type requestBodyKey struct {
+	operationName  string
+	query          string
+	emptyVariables bool
+}
+
+// requestWithVariables is a Request always sending its variables, for SendEmptyVariables
+type requestWithVariables struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// requestBody marshals the request body, bodies of operations sent without variables
+// never change and are marshalled once then reused
+func (c *Client) requestBody(operationName, query string, vars map[string]interface{}) ([]byte, error) {
+	isStatic := len(vars) == 0 && c.requestBodies != nil
+	key := requestBodyKey{operationName: operationName, query: query, emptyVariables: c.SendEmptyVariables}
+	if isStatic {
+		if body, ok := c.requestBodies.Load(key); ok {
+			return body.([]byte), nil
+		}
+	}
+
+	// Create request object
+	// Fill query
+	// Fill variables
+	// Fill operation name, selecting the operation to execute in the query
+	var r interface{} = &Request{
+		Query:         query,
+		Variables:     vars,
+		OperationName: operationName,
+	}
+	if len(vars) == 0 && c.SendEmptyVariables {
+		r = &requestWithVariables{
+			Query:         query,
+			Variables:     map[string]interface{}{},
+			OperationName: operationName,
+		}
+	}
+
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if isStatic {
+		c.requestBodies.Store(key, body)
+	}
+
+	return body, nil
+}
+
 // GqlErrorList is the struct of a standard graphql error response
 type GqlErrorList struct {
 	Errors gqlerror.List `json:"errors"`
@@ -165,12 +487,59 @@ type HTTPError struct {
 	Message string `json:"message"`
 }
 
-// ErrorResponse represent an handled error
+// Sentinel errors matching an HTTPError, and an ErrorResponse holding it, by status code with errors.Is
+var (
+	// ErrUnauthenticated matches 401 Unauthorized responses
+	ErrUnauthenticated = xerrors.New("unauthenticated")
+	// ErrForbidden matches 403 Forbidden responses
+	ErrForbidden = xerrors.New("forbidden")
+	// ErrRateLimited matches 429 Too Many Requests responses
+	ErrRateLimited = xerrors.New("rate limited")
+	// ErrServer matches 5xx responses
+	ErrServer = xerrors.New("server error")
+)
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http status %d: %s", e.Code, e.Message)
+}
+
+// Is reports whether the status code belongs to the category of the target sentinel error
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrUnauthenticated:
+		return e.Code == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.Code == http.StatusForbidden
+	case ErrRateLimited:
+		return e.Code == http.StatusTooManyRequests
+	case ErrServer:
+		return e.Code >= http.StatusInternalServerError && e.Code <= 599
+	}
+
+	return false
+}
+
+// ErrorPrecedence is which of the errors of an ErrorResponse holding both a NetworkError and GqlErrors comes first,
+// in its message and for Unwrap, errors.Is and errors.As
+type ErrorPrecedence int
+
+const (
+	// NetworkErrorFirst puts the NetworkError before the GqlErrors
+	NetworkErrorFirst ErrorPrecedence = iota
+	// GqlErrorsFirst puts the GqlErrors before the NetworkError
+	GqlErrorsFirst
+)
+
+// ErrorResponse represent an handled error, both fields are set when a non 2xx response holds graphql errors.
+// errors.Is matches the sentinel errors of the status code and the graphql errors, errors.As finds the HTTPError,
+// the *gqlerror.Error, the gqlerror.List and an ErrorResponse value or pointer.
 type ErrorResponse struct {
-	// populated when http status code is not OK
+	// populated when the http status code is not 2xx
 	NetworkError *HTTPError `json:"networkErrors"`
-	// populated when http status code is OK but the server returned at least one graphql error
+	// populated when the response holds at least one graphql error, whatever the http status code
 	GqlErrors *gqlerror.List `json:"graphqlErrors"`
+	// Precedence orders NetworkError and GqlErrors, the Client.ErrorPrecedence of the client returning the error
+	Precedence ErrorPrecedence `json:"-"`
 }
 
 // HasErrors returns true when at least one error is declared
@@ -178,8 +547,125 @@ func (er *ErrorResponse) HasErrors() bool {
 	return er.NetworkError != nil || er.GqlErrors != nil
 }
 
-func (er *ErrorResponse) Error() string {
-	content, err := json.Marshal(er)
+// errs returns the NetworkError and the graphql errors in the order of Precedence
+func (er *ErrorResponse) errs() []error {
+	var gqlErrs []error
+	if er.GqlErrors != nil {
+		for _, gqlErr := range *er.GqlErrors {
+			gqlErrs = append(gqlErrs, gqlErr)
+		}
+	}
+
+	if er.NetworkError == nil {
+		return gqlErrs
+	}
+	if er.Precedence == GqlErrorsFirst {
+		return append(gqlErrs, er.NetworkError)
+	}
+
+	return append([]error{er.NetworkError}, gqlErrs...)
+}
+
+// Unwrap returns the first error in the order of Precedence, nil when there is none
+func (er *ErrorResponse) Unwrap() error {
+	errs := er.errs()
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs[0]
+}
+
+// Is reports whether the NetworkError or one of the graphql errors matches target
+func (er *ErrorResponse) Is(target error) bool {
+	for _, err := range er.errs() {
+		if xerrors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As sets target to er when it points to an ErrorResponse, to the graphql errors when it points to a gqlerror.List,
+// and otherwise to the first of the NetworkError and the graphql errors matching it in the order of Precedence
+func (er *ErrorResponse) As(target interface{}) bool {
+	switch target := target.(type) {
+	case *ErrorResponse:
+		*target = *er
+
+		return true
+	case *gqlerror.List:
+		if er.GqlErrors == nil {
+			return false
+		}
+		*target = *er.GqlErrors
+
+		return true
+	}
+
+	for _, err := range er.errs() {
+		if xerrors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrorsByPath groups the graphql errors by the response path of the field they belong to, such as
+// "user.posts[0].title", the errors not attributed to a field being under ""
+func (er *ErrorResponse) ErrorsByPath() map[string]gqlerror.List {
+	if er.GqlErrors == nil {
+		return nil
+	}
+
+	byPath := make(map[string]gqlerror.List)
+	for _, gqlErr := range *er.GqlErrors {
+		path := gqlErr.Path.String()
+		byPath[path] = append(byPath[path], gqlErr)
+	}
+
+	return byPath
+}
+
+// ErrorsAt returns the graphql errors of the field at path, such as "user.posts[0]", and of the fields it holds
+func (er *ErrorResponse) ErrorsAt(path string) gqlerror.List {
+	if er.GqlErrors == nil {
+		return nil
+	}
+
+	var errs gqlerror.List
+	for _, gqlErr := range *er.GqlErrors {
+		if len(gqlErr.Path) == 0 {
+			continue
+		}
+
+		errPath := gqlErr.Path.String()
+		if errPath == path || strings.HasPrefix(errPath, path+".") || strings.HasPrefix(errPath, path+"[") {
+			errs = append(errs, gqlErr)
+		}
+	}
+
+	return errs
+}
+
+// Error is the JSON form of the errors, in the order of Precedence. Its value receiver lets
+// errors.As(err, &ErrorResponse{}) copy the ErrorResponse of err.
+func (er ErrorResponse) Error() string {
+	var content []byte
+	var err error
+	if er.Precedence == GqlErrorsFirst {
+		content, err = json.Marshal(struct {
+			GqlErrors    *gqlerror.List `json:"graphqlErrors"`
+			NetworkError *HTTPError     `json:"networkErrors"`
+		}{er.GqlErrors, er.NetworkError})
+	} else {
+		content, err = json.Marshal(struct {
+			NetworkError *HTTPError     `json:"networkErrors"`
+			GqlErrors    *gqlerror.List `json:"graphqlErrors"`
+		}{er.NetworkError, er.GqlErrors})
+	}
 	if err != nil {
 		return err.Error()
 	}
@@ -187,43 +673,237 @@ func (er *ErrorResponse) Error() string {
 	return string(content)
 }
 
+// ErrorCodes returns the extensions.code of the graphql errors of err, an ErrorResponse, skipping the errors without code
+func ErrorCodes(err error) []string {
+	var errResponse *ErrorResponse
+	if !xerrors.As(err, &errResponse) || errResponse.GqlErrors == nil {
+		return nil
+	}
+
+	var codes []string
+	for _, gqlErr := range *errResponse.GqlErrors {
+		if code, ok := gqlErr.Extensions["code"].(string); ok {
+			codes = append(codes, code)
+		}
+	}
+
+	return codes
+}
+
+// UserMessages returns the messages to display for the graphql errors of err, an ErrorResponse: the
+// extensions.userMessage of each error, or its message when missing, in order and without duplicates
+func UserMessages(err error) []string {
+	var errResponse *ErrorResponse
+	if !xerrors.As(err, &errResponse) || errResponse.GqlErrors == nil {
+		return nil
+	}
+
+	var messages []string
+	seen := make(map[string]bool)
+	for _, gqlErr := range *errResponse.GqlErrors {
+		message, ok := gqlErr.Extensions["userMessage"].(string)
+		if !ok || message == "" {
+			message = gqlErr.Message
+		}
+		if message == "" || seen[message] {
+			continue
+		}
+		seen[message] = true
+		messages = append(messages, message)
+	}
+
+	return messages
+}
+
 // Post sends a http POST request to the graphql endpoint with the given query then unpacks
 // the response into the given object.
 func (c *Client) Post(ctx context.Context, operationName, query string, respData interface{}, vars map[string]interface{}, httpRequestOptions ...HTTPRequestOption) error {
-	req, err := c.newRequest(ctx, operationName, query, vars, httpRequestOptions)
+	body, statusCode, header, err := c.cachedDo(ctx, operationName, query, vars, httpRequestOptions)
+	if err == nil {
+		err = c.parse(body, statusCode, header, respData)
+	}
+	c.onResponse(operationName, respData, err)
+
+	return err
+}
+
+// PostRaw is Post also returning the undecoded data of the response, nil when the response has no data or null data
+func (c *Client) PostRaw(ctx context.Context, operationName, query string, respData interface{}, vars map[string]interface{}, httpRequestOptions ...HTTPRequestOption) (json.RawMessage, error) {
+	body, statusCode, header, err := c.cachedDo(ctx, operationName, query, vars, httpRequestOptions)
 	if err != nil {
-		return xerrors.Errorf("don't create request: %w", err)
+		c.onResponse(operationName, respData, err)
+
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-	req.Header.Set("Accept", "application/json; charset=utf-8")
+	// a malformed body is reported by parseResponse
+	var raw response
+	_ = json.Unmarshal(body, &raw)
+	if string(raw.Data) == "null" {
+		raw.Data = nil
+	}
+
+	err = c.parse(body, statusCode, header, respData)
+	c.onResponse(operationName, respData, err)
+
+	return raw.Data, err
+}
+
+// do sends the request to the graphql endpoint and returns the raw response body, http status code and header,
+// retrying the failed attempts up to MaxRetries times while RetryBudget allows it, each attempt bounded by RequestTimeout
+func (c *Client) do(ctx context.Context, operationName, query string, vars map[string]interface{}, httpRequestOptions []HTTPRequestOption) ([]byte, int, http.Header, error) {
+	if c.Transport == nil {
+		var err error
+		if httpRequestOptions, err = c.idempotencyKey(operationName, query, httpRequestOptions); err != nil {
+			return nil, 0, nil, err
+		}
+	}
+
+	return c.retry(ctx, operationName, query, vars, func(ctx context.Context) ([]byte, int, http.Header, error) {
+		attemptCtx, cancel := c.withRequestTimeout(ctx)
+		defer cancel()
+
+		return c.doAttempt(attemptCtx, operationName, query, vars, httpRequestOptions)
+	})
+}
+
+// retry calls call until it succeeds, fails with an error that is not retriable or the retries are exhausted
+func (c *Client) retry(ctx context.Context, operationName, query string, vars map[string]interface{}, call func(ctx context.Context) ([]byte, int, http.Header, error)) ([]byte, int, http.Header, error) {
+	maxRetries := c.maxRetries(ctx)
+	if maxRetries > 0 && len(collectUploads(vars)) > 0 {
+		// the files are read by the first attempt
+		maxRetries = 0
+	}
+	c.RetryBudget.deposit()
+
+	for attempt := 0; ; attempt++ {
+		body, statusCode, header, err := call(ctx)
+		// retriable excludes the attempts which did not reach the endpoint, they do not spend the RetryBudget
+		if attempt >= maxRetries || !c.retriable(ctx, operationName, query, statusCode, err) || !c.RetryBudget.withdraw() {
+			return body, statusCode, header, err
+		}
+
+		if err := sleep(ctx, c.retryDelay(attempt, header)); err != nil {
+			return nil, 0, nil, xerrors.Errorf("%s: waiting to retry: %w", operationName, err)
+		}
+	}
+}
+
+// doAttempt sends the request once through Transport or HTTP
+func (c *Client) doAttempt(ctx context.Context, operationName, query string, vars map[string]interface{}, httpRequestOptions []HTTPRequestOption) ([]byte, int, http.Header, error) {
+	if c.Transport != nil {
+		return c.doTransport(ctx, operationName, query, vars)
+	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.send(ctx, operationName, query, vars, httpRequestOptions)
 	if err != nil {
-		return xerrors.Errorf("request failed: %w", err)
+		return nil, 0, nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return xerrors.Errorf("failed to read response body: %w", err)
+		return nil, 0, nil, xerrors.Errorf("failed to read response body: %w", err)
+	}
+
+	return body, resp.StatusCode, resp.Header, nil
+}
+
+// send sends the request to the graphql endpoint, the caller must close the response body
+func (c *Client) send(ctx context.Context, operationName, query string, vars map[string]interface{}, httpRequestOptions []HTTPRequestOption) (*http.Response, error) {
+	if err := c.checkAllowed(operationName); err != nil {
+		return nil, err
+	}
+
+	if err := validateVariables(vars); err != nil {
+		return nil, xerrors.Errorf("%s: %w", operationName, err)
+	}
+
+	c.onRequest(operationName, vars)
+
+	req, err := c.newRequest(ctx, operationName, query, vars, httpRequestOptions)
+	if err != nil {
+		return nil, xerrors.Errorf("don't create request: %w", err)
+	}
+
+	accept := c.Accept
+	if accept == "" {
+		accept = DefaultAccept
+	}
+	req.Header.Set("Accept", accept)
+
+	release, err := c.acquireRequestSlot(ctx, operationName)
+	if err != nil {
+		return nil, err
 	}
 
-	return parseResponse(body, resp.StatusCode, respData)
+	// The credentials of the request are masked in the errors and error responses
+	secret := authorizationSecret(req.Header)
+	resp, err := c.doHTTP(req)
+	if err != nil {
+		release()
+
+		return nil, xerrors.Errorf("%s (%s %s): request failed: %w", operationName, req.Method, req.URL.Redacted(), &sendError{err: redactError(err, secret)})
+	}
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+	if secret != "" && (resp.StatusCode < 200 || 299 < resp.StatusCode) {
+		if err := redactResponseBody(resp, secret); err != nil {
+			return nil, xerrors.Errorf("failed to read response body: %w", err)
+		}
+	}
+	c.onServerTiming(operationName, resp.Header)
+
+	return resp, nil
+}
+
+// checkAllowed returns ErrOperationNotAllowed when operationName is missing from AllowedOperations
+func (c *Client) checkAllowed(operationName string) error {
+	if c.AllowedOperations != nil && !c.AllowedOperations[operationName] {
+		return xerrors.Errorf("%s: %w", operationName, ErrOperationNotAllowed)
+	}
+
+	return nil
 }
 
-func parseResponse(body []byte, httpCode int, result interface{}) error {
+// UnmarshalData decodes GraphQL response data into v with the decoding options of the client
+func (c *Client) UnmarshalData(data json.RawMessage, v interface{}) error {
+	return graphqljson.UnmarshalData(data, v, c.decodeOptions()...)
+}
+
+func (c *Client) decodeOptions() []graphqljson.Option {
+	var options []graphqljson.Option
+	if c.DisallowUnknownFields {
+		options = append(options, graphqljson.DisallowUnknownFields())
+	}
+	if c.UseNumber {
+		options = append(options, graphqljson.UseNumber())
+	}
+
+	return options
+}
+
+// parse is parseResponse with the decode options and the ErrorPrecedence of the client
+func (c *Client) parse(body []byte, httpCode int, header http.Header, result interface{}) error {
+	err := parseResponse(body, httpCode, header, result, c.decodeOptions()...)
+	if errResponse, ok := err.(*ErrorResponse); ok {
+		errResponse.Precedence = c.ErrorPrecedence
+	}
+
+	return err
+}
+
+func parseResponse(body []byte, httpCode int, header http.Header, result interface{}, decodeOptions ...graphqljson.Option) error {
 	errResponse := &ErrorResponse{}
 	isKOCode := httpCode < 200 || 299 < httpCode
 	if isKOCode {
 		errResponse.NetworkError = &HTTPError{
 			Code:    httpCode,
-			Message: fmt.Sprintf("Response body %s", string(body)),
+			Message: errorStatusMessage(body, httpCode, header),
 		}
 	}
 
 	// some servers return a graphql error with a non OK http code, try anyway to parse the body
-	if err := unmarshal(body, result); err != nil {
+	if err := unmarshal(body, result, decodeOptions...); err != nil {
 		if gqlErr, ok := err.(*GqlErrorList); ok {
 			errResponse.GqlErrors = &gqlErr.Errors
 		} else if !isKOCode { // if is KO code there is already the http error, this error should not be returned
@@ -238,29 +918,74 @@ func parseResponse(body []byte, httpCode int, result interface{}) error {
 	return nil
 }
 
+// GraphQLResponseMediaType is the media type of the GraphQL over HTTP specification, whose non 2xx responses are
+// GraphQL responses too, unlike the ones of the legacy application/json media type which may come from a proxy
+const GraphQLResponseMediaType = "application/graphql-response+json"
+
+// isGraphQLResponse reports whether the body is a well-formed GraphQL response of GraphQLResponseMediaType,
+// holding data or errors
+func isGraphQLResponse(body []byte, header http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || mediaType != GraphQLResponseMediaType {
+		return false
+	}
+
+	var resp response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return false
+	}
+
+	return len(resp.Data) > 0 || len(resp.Errors) > 0
+}
+
+// errorStatusMessage is the message of the HTTPError of a non 2xx response, the status text for the GraphQL
+// responses whose errors are reported as the graphql errors, the body otherwise
+func errorStatusMessage(body []byte, httpCode int, header http.Header) string {
+	if isGraphQLResponse(body, header) {
+		return http.StatusText(httpCode)
+	}
+
+	return fmt.Sprintf("Response body %s", string(body))
+}
+
 // response is a GraphQL layer response from a handler.
 type response struct {
 	Data   json.RawMessage `json:"data"`
 	Errors json.RawMessage `json:"errors"`
 }
 
-func unmarshal(data []byte, res interface{}) error {
+func unmarshal(data []byte, res interface{}, decodeOptions ...graphqljson.Option) error {
 	resp := response{}
 	if err := json.Unmarshal(data, &resp); err != nil {
 		return xerrors.Errorf("failed to decode data %s: %w", string(data), err)
 	}
 
-	if resp.Errors != nil && len(resp.Errors) > 0 {
+	// errors is absent, null or empty when the server did not return any
+	errors := &GqlErrorList{}
+	if len(resp.Errors) > 0 {
 		// try to parse standard graphql error
-		errors := &GqlErrorList{}
 		if e := json.Unmarshal(data, errors); e != nil {
 			return xerrors.Errorf("faild to parse graphql errors. Response content %s - %w ", string(data), e)
 		}
-
+	}
+	if len(errors.Errors) > 0 {
 		return errors
 	}
 
-	if err := graphqljson.UnmarshalData(resp.Data, res); err != nil {
+	// raw data is returned as is
+	if raw, ok := res.(*json.RawMessage); ok {
+		*raw = resp.Data
+
+		return nil
+	}
+
+	// data is absent when the request failed before execution and null when it failed at the root,
+	// there is nothing to decode
+	if len(resp.Data) == 0 || string(resp.Data) == "null" {
+		return nil
+	}
+
+	if err := graphqljson.UnmarshalData(resp.Data, res, decodeOptions...); err != nil {
 		return xerrors.Errorf("failed to decode data into response %s: %w", string(data), err)
 	}
 
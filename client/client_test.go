@@ -1,11 +1,26 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 	"golang.org/x/xerrors"
@@ -155,6 +170,37 @@ func TestUnmarshal(t *testing.T) {
 		require.EqualError(t, err, "failed to decode data into response {\"data\": \"notAndObject\"}: : : : json: cannot unmarshal string into Go value of type client.fakeRes")
 	})
 
+	t.Run("errors only", func(t *testing.T) {
+		t.Parallel()
+		expectedErr := &GqlErrorList{Errors: gqlerror.List{{Message: "boom", Extensions: map[string]interface{}{"code": "INTERNAL"}}}}
+		for _, body := range []string{
+			`{"errors":[{"message":"boom","extensions":{"code":"INTERNAL"}}]}`,
+			`{"errors":[{"message":"boom","extensions":{"code":"INTERNAL"}}],"data":null}` + "\r\n",
+		} {
+			r := &fakeRes{}
+			require.Equal(t, expectedErr, unmarshal([]byte(body), r), body)
+			require.Equal(t, &fakeRes{}, r)
+		}
+	})
+
+	t.Run("no errors", func(t *testing.T) {
+		t.Parallel()
+		for _, body := range []string{
+			`{"data":{"something":"some data"},"errors":null}`,
+			`{"data":{"something":"some data"},"errors":[]}`,
+		} {
+			r := &fakeRes{}
+			require.NoError(t, unmarshal([]byte(body), r), body)
+			require.Equal(t, "some data", r.Something)
+		}
+
+		for _, body := range []string{`{}`, `{"errors":[]}`, `{"data":null}`} {
+			r := &fakeRes{}
+			require.NoError(t, unmarshal([]byte(body), r), body)
+			require.Equal(t, &fakeRes{}, r)
+		}
+	})
+
 	t.Run("bad data format", func(t *testing.T) {
 		t.Parallel()
 		r := &fakeRes{}
@@ -168,7 +214,7 @@ func TestParseResponse(t *testing.T) {
 	t.Run("single error", func(t *testing.T) {
 		t.Parallel()
 		r := &fakeRes{}
-		err := parseResponse([]byte(qqlSingleErr), 200, r)
+		err := parseResponse([]byte(qqlSingleErr), 200, nil, r)
 
 		expectedType := &ErrorResponse{}
 		require.IsType(t, expectedType, err)
@@ -182,7 +228,7 @@ func TestParseResponse(t *testing.T) {
 	t.Run("bad error format", func(t *testing.T) {
 		t.Parallel()
 		r := &fakeRes{}
-		err := parseResponse([]byte(withBadErrorsFormat), 200, r)
+		err := parseResponse([]byte(withBadErrorsFormat), 200, nil, r)
 
 		expectedType := xerrors.Errorf("%w", errors.New("some"))
 		require.IsType(t, expectedType, err)
@@ -191,7 +237,7 @@ func TestParseResponse(t *testing.T) {
 	t.Run("network error with valid gql error response", func(t *testing.T) {
 		t.Parallel()
 		r := &fakeRes{}
-		err := parseResponse([]byte(qqlSingleErr), 400, r)
+		err := parseResponse([]byte(qqlSingleErr), 400, nil, r)
 
 		expectedType := &ErrorResponse{}
 		require.IsType(t, expectedType, err)
@@ -206,7 +252,7 @@ func TestParseResponse(t *testing.T) {
 	t.Run("network error with not valid gql error response", func(t *testing.T) {
 		t.Parallel()
 		r := &fakeRes{}
-		err := parseResponse([]byte(invalidJSON), 500, r)
+		err := parseResponse([]byte(invalidJSON), 500, nil, r)
 
 		expectedType := &ErrorResponse{}
 		require.IsType(t, expectedType, err)
@@ -220,8 +266,2023 @@ func TestParseResponse(t *testing.T) {
 	t.Run("no error", func(t *testing.T) {
 		t.Parallel()
 		r := &fakeRes{}
-		err := parseResponse([]byte(validData), 200, r)
+		err := parseResponse([]byte(validData), 200, nil, r)
 
 		require.Nil(t, err)
 	})
 }
+
+func TestParseTypedResponse(t *testing.T) {
+	t.Parallel()
+	t.Run("valid data", func(t *testing.T) {
+		t.Parallel()
+		resp, err := parseTypedResponse[fakeRes]([]byte(validData), 200, nil)
+		require.NoError(t, err)
+		require.Equal(t, &fakeRes{Something: "some data"}, resp.Data)
+		require.False(t, resp.HasErrors())
+	})
+
+	t.Run("data and error", func(t *testing.T) {
+		t.Parallel()
+		resp, err := parseTypedResponse[fakeRes]([]byte(gqlDataAndErr), 200, nil)
+		require.NoError(t, err)
+		require.Equal(t, &fakeRes{Something: "some data"}, resp.Data)
+		require.Len(t, resp.Errors, 1)
+		require.Equal(t, "Field 'nsodes' doesn't exist on type 'RepositoryConnection'", resp.Errors[0].Message)
+	})
+
+	t.Run("errors only", func(t *testing.T) {
+		t.Parallel()
+		resp, err := parseTypedResponse[fakeRes]([]byte(gqlMultipleErr), 200, nil)
+		require.NoError(t, err)
+		require.Nil(t, resp.Data)
+		require.Len(t, resp.Errors, 3)
+	})
+
+	t.Run("extensions", func(t *testing.T) {
+		t.Parallel()
+		resp, err := parseTypedResponse[fakeRes]([]byte(`{"data":{"something":"some data"},"extensions":{"cost":3}}`), 200, nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"cost": float64(3)}, resp.Extensions)
+	})
+
+	t.Run("network error with valid gql error response", func(t *testing.T) {
+		t.Parallel()
+		resp, err := parseTypedResponse[fakeRes]([]byte(qqlSingleErr), 400, nil)
+		require.IsType(t, &ErrorResponse{}, err)
+		require.Equal(t, 400, err.(*ErrorResponse).NetworkError.Code)
+		require.Len(t, *err.(*ErrorResponse).GqlErrors, 1)
+		require.Len(t, resp.Errors, 1)
+	})
+
+	t.Run("network error with not valid gql error response", func(t *testing.T) {
+		t.Parallel()
+		resp, err := parseTypedResponse[fakeRes]([]byte(invalidJSON), 500, nil)
+		require.Nil(t, resp)
+		require.IsType(t, &ErrorResponse{}, err)
+		require.Nil(t, err.(*ErrorResponse).GqlErrors)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		t.Parallel()
+		_, err := parseTypedResponse[fakeRes]([]byte(invalidJSON), 200, nil)
+		require.EqualError(t, err, "failed to decode data invalid: invalid character 'i' looking for beginning of value")
+	})
+}
+
+func TestMarshalResponse(t *testing.T) {
+	t.Parallel()
+	body, err := MarshalResponse(&fakeRes{Something: "some data"})
+	require.NoError(t, err)
+	require.Equal(t, `{"data":{"something":"some data"}}`, string(body))
+
+	var res fakeRes
+	require.NoError(t, parseResponse(body, http.StatusOK, nil, &res))
+	require.Equal(t, fakeRes{Something: "some data"}, res)
+}
+
+func TestFormatTimeVariables(t *testing.T) {
+	t.Parallel()
+
+	type input struct {
+		At       time.Time  `json:"at"`
+		Optional *time.Time `json:"optional,omitempty"`
+		Name     string     `json:"name"`
+		Ignored  string     `json:"-"`
+	}
+
+	at := time.Date(2021, 1, 2, 3, 4, 5, 6000, time.FixedZone("JST", 9*60*60))
+	vars := map[string]interface{}{
+		"at":    at,
+		"ptr":   &at,
+		"list":  []time.Time{at},
+		"input": input{At: at, Name: "name", Ignored: "ignored"},
+		"id":    int64(9007199254740993),
+		"nil":   nil,
+	}
+
+	formatted := formatTimeVariables(vars, "2006-01-02T15:04:05Z")
+	require.Equal(t, map[string]interface{}{
+		"at":    "2021-01-01T18:04:05Z",
+		"ptr":   "2021-01-01T18:04:05Z",
+		"list":  []interface{}{"2021-01-01T18:04:05Z"},
+		"input": map[string]interface{}{"at": "2021-01-01T18:04:05Z", "name": "name"},
+		"id":    int64(9007199254740993),
+		"nil":   nil,
+	}, formatted)
+}
+
+func TestAllowedOperations(t *testing.T) {
+	t.Parallel()
+	c := NewClient(ClientOptions{
+		AllowedOperations: map[string]bool{"GetUser": true},
+	})
+
+	err := c.Post(context.Background(), "DeleteUser", "mutation DeleteUser { deleteUser }", &fakeRes{}, nil)
+	require.True(t, xerrors.Is(err, ErrOperationNotAllowed))
+	require.EqualError(t, err, "DeleteUser: operation not allowed")
+}
+
+func TestDecodeStream(t *testing.T) {
+	t.Parallel()
+	t.Run("elements", func(t *testing.T) {
+		t.Parallel()
+		var elements []string
+		err := decodeStream(strings.NewReader(`{"data":{"items":[{"id":1},{"id":2}],"other":{"a":[1]}},"extensions":{}}`), "items", func(element json.RawMessage) error {
+			elements = append(elements, string(element))
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{`{"id":1}`, `{"id":2}`}, elements)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		err := decodeStream(strings.NewReader(`{"data":null,"errors":[{"message":"boom"}]}`), "items", func(element json.RawMessage) error {
+			t.Fatal("unexpected element")
+
+			return nil
+		})
+		require.IsType(t, &ErrorResponse{}, err)
+		require.Equal(t, "boom", (*err.(*ErrorResponse).GqlErrors)[0].Message)
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		t.Parallel()
+		err := decodeStream(strings.NewReader(`{"data":{"items":[1,2]}}`), "items", func(element json.RawMessage) error {
+			return errors.New("stop")
+		})
+		require.EqualError(t, err, "stop")
+	})
+
+	t.Run("not a list", func(t *testing.T) {
+		t.Parallel()
+		err := decodeStream(strings.NewReader(`{"data":{"items":{}}}`), "items", func(element json.RawMessage) error {
+			return nil
+		})
+		require.EqualError(t, err, "field items is not a list")
+	})
+}
+
+func TestPostStream(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	var mu sync.Mutex
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		mu.Unlock()
+		switch r.Header.Get("X-Test") {
+		case "slow":
+			time.Sleep(50 * time.Millisecond)
+		case "flaky":
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+		case "errors":
+			_, _ = w.Write([]byte(`{"data":null,"errors":[{"message":"boom"}]}`))
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"items":[1,2]}}`))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL, RequestTimeout: 10 * time.Millisecond, RetryBackoff: time.Millisecond, IdempotencyKeyHeader: "Idempotency-Key", ErrorPrecedence: GqlErrorsFirst})
+	var items []string
+	onElement := func(element json.RawMessage) error {
+		items = append(items, string(element))
+
+		return nil
+	}
+
+	// the failed attempt is retried with the same idempotency key, the body of the successful one is read
+	// within its RequestTimeout
+	ctx := WithMaxRetries(context.Background(), 1)
+	require.NoError(t, c.PostStream(ctx, "SetItems", "mutation SetItems { items }", "items", nil, onElement, WithHeader("X-Test", "flaky")))
+	require.Equal(t, []string{"1", "2"}, items)
+	require.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	mu.Lock()
+	require.Len(t, keys, 2)
+	require.NotEmpty(t, keys[0])
+	require.Equal(t, keys[0], keys[1])
+	mu.Unlock()
+
+	err := c.PostStream(context.Background(), "Items", "query Items { items }", "items", nil, onElement, WithHeader("X-Test", "slow"))
+	require.True(t, xerrors.Is(err, context.DeadlineExceeded), err)
+
+	err = c.PostStream(context.Background(), "Items", "query Items { items }", "items", nil, onElement, WithHeader("X-Test", "errors"))
+	require.IsType(t, &ErrorResponse{}, err)
+	require.Equal(t, GqlErrorsFirst, err.(*ErrorResponse).Precedence)
+}
+
+func TestRequestBody(t *testing.T) {
+	t.Parallel()
+	c := NewClient(ClientOptions{})
+
+	body, err := c.requestBody("GetUser", "query GetUser { user { id } }", nil)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"query":"query GetUser { user { id } }","operationName":"GetUser"}`, string(body))
+
+	cached, err := c.requestBody("GetUser", "query GetUser { user { id } }", map[string]interface{}{})
+	require.NoError(t, err)
+	require.Equal(t, &body[0], &cached[0])
+
+	withVars, err := c.requestBody("GetUser", "query GetUser($id: ID!) { user(id: $id) { id } }", map[string]interface{}{"id": "1"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"query":"query GetUser($id: ID!) { user(id: $id) { id } }","variables":{"id":"1"},"operationName":"GetUser"}`, string(withVars))
+}
+
+func TestOptional(t *testing.T) {
+	t.Parallel()
+
+	type input struct {
+		Set   Optional[string] `json:"set,omitzero"`
+		Null  Optional[string] `json:"null,omitzero"`
+		Unset Optional[string] `json:"unset,omitzero"`
+	}
+
+	in := input{Set: OptionalOf("value"), Null: OptionalNull[string]()}
+	content, err := json.Marshal(in)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"set":"value","null":null}`, string(content))
+
+	formatted := formatTimeVariables(map[string]interface{}{"input": in}, time.RFC3339)
+	require.Equal(t, map[string]interface{}{"input": map[string]interface{}{"set": in.Set, "null": in.Null}}, formatted)
+
+	// the unset Optionals are omitted from the variables sent by the client, whatever the version of encoding/json
+	now := time.Now()
+	require.True(t, hasOptionals(reflect.ValueOf(map[string]interface{}{"input": []*input{&in}})))
+	require.False(t, hasOptionals(reflect.ValueOf(map[string]interface{}{"at": now, "ids": []string{"a"}})))
+	formatted = formatTimeVariables(map[string]interface{}{"input": in, "at": now}, "")
+	require.Equal(t, map[string]interface{}{"input": map[string]interface{}{"set": in.Set, "null": in.Null}, "at": now}, formatted)
+	req, err := (&Client{BaseURL: "http://localhost"}).newRequest(context.Background(), "SetSomething", "mutation SetSomething($input: Input) { something }", map[string]interface{}{"input": in}, nil)
+	require.NoError(t, err)
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"operationName":"SetSomething","query":"mutation SetSomething($input: Input) { something }","variables":{"input":{"set":"value","null":null}}}`, string(body))
+
+	var out input
+	require.NoError(t, json.Unmarshal([]byte(`{"set":"value","null":null}`), &out))
+	require.Equal(t, in, out)
+
+	value, ok := out.Set.Get()
+	require.True(t, ok)
+	require.Equal(t, "value", value)
+	require.True(t, out.Null.IsNull())
+	require.False(t, out.Unset.IsSet())
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	var status int32 = http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	breaker := NewCircuitBreaker(2, time.Minute).(*consecutiveFailuresBreaker)
+	breaker.now = func() time.Time { return now }
+	c := &Client{Client: server.Client(), CircuitBreaker: breaker}
+
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodPost, server.URL, nil)
+		require.NoError(t, err)
+
+		resp, err := c.doHTTP(req)
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		return resp, err
+	}
+
+	// 5xx responses are returned and counted as failures
+	for i := 0; i < 2; i++ {
+		resp, err := send()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	}
+
+	_, err := send()
+	require.Equal(t, ErrCircuitOpen, err)
+
+	// after the cooldown a successful trial closes the circuit
+	now = now.Add(time.Minute)
+	atomic.StoreInt32(&status, http.StatusOK)
+	resp, err := send()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 0, breaker.failures)
+}
+
+func TestLargeNumbers(t *testing.T) {
+	t.Parallel()
+
+	type input struct {
+		ID  int64     `json:"id"`
+		At  time.Time `json:"at"`
+		Max uint64    `json:"max"`
+	}
+
+	vars := map[string]interface{}{
+		"id":    int64(9007199254740993),
+		"max":   uint64(18446744073709551615),
+		"input": input{ID: 9007199254740993, Max: 18446744073709551615},
+	}
+
+	// variables keep their Go types up to the encoding, integers are never coerced to float64
+	c := NewClient(ClientOptions{TimeLayout: time.RFC3339})
+	body, err := c.requestBody("Op", "query Op { op }", formatTimeVariables(vars, c.TimeLayout))
+	require.NoError(t, err)
+	require.Contains(t, string(body), `"id":9007199254740993`)
+	require.Contains(t, string(body), `"max":18446744073709551615`)
+	require.Contains(t, string(body), `"input":{"at":"0001-01-01T00:00:00Z","id":9007199254740993,"max":18446744073709551615}`)
+
+	// and are decoded back without loss of precision
+	var res struct {
+		ID  int64  `graphql:"id"`
+		Max uint64 `graphql:"max"`
+	}
+	require.NoError(t, unmarshal([]byte(`{"data":{"id":9007199254740993,"max":18446744073709551615}}`), &res))
+	require.Equal(t, int64(9007199254740993), res.ID)
+	require.Equal(t, uint64(18446744073709551615), res.Max)
+}
+
+func TestHTTPErrorKinds(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		code     int
+		expected error
+	}{
+		{code: http.StatusUnauthorized, expected: ErrUnauthenticated},
+		{code: http.StatusForbidden, expected: ErrForbidden},
+		{code: http.StatusTooManyRequests, expected: ErrRateLimited},
+		{code: http.StatusInternalServerError, expected: ErrServer},
+		{code: http.StatusServiceUnavailable, expected: ErrServer},
+	}
+	sentinels := []error{ErrUnauthenticated, ErrForbidden, ErrRateLimited, ErrServer}
+
+	for _, tt := range tests {
+		err := xerrors.Errorf("wrapped: %w", parseResponse([]byte(invalidJSON), tt.code, nil, &fakeRes{}))
+		for _, sentinel := range sentinels {
+			require.Equal(t, sentinel == tt.expected, errors.Is(err, sentinel), "%d is %v", tt.code, sentinel)
+		}
+	}
+
+	err := parseResponse([]byte(qqlSingleErr), http.StatusOK, nil, &fakeRes{})
+	for _, sentinel := range sentinels {
+		require.False(t, errors.Is(err, sentinel))
+	}
+}
+
+func TestOperationAuthorizers(t *testing.T) {
+	t.Parallel()
+	bearer := func(token string) Authorizer {
+		return AuthorizerFunc(func(ctx context.Context, req *http.Request) error {
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			return nil
+		})
+	}
+	c := NewClient(ClientOptions{
+		Authorizers: map[string]Authorizer{
+			"public": bearer("public"),
+			"admin":  bearer("admin"),
+		},
+		OperationAuthorizers: map[string]string{
+			"GetUser":    "public",
+			"DeleteUser": "admin",
+			"Broken":     "missing",
+		},
+	})
+
+	tests := []struct {
+		operationName string
+		expected      string
+	}{
+		{operationName: "GetUser", expected: "Bearer public"},
+		{operationName: "DeleteUser", expected: "Bearer admin"},
+		{operationName: "Other", expected: ""},
+	}
+	for _, tt := range tests {
+		req, err := c.newRequest(context.Background(), tt.operationName, "query { a }", nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, req.Header.Get("Authorization"), tt.operationName)
+	}
+
+	_, err := c.newRequest(context.Background(), "Broken", "query { a }", nil, nil)
+	require.EqualError(t, err, `unknown authorizer "missing" for operation Broken`)
+
+	req, err := c.newRequest(WithBearerToken(context.Background(), "user.jwt"), "DeleteUser", "query { a }", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer user.jwt", req.Header.Get("Authorization"))
+}
+
+func TestUnknownFields(t *testing.T) {
+	t.Parallel()
+	body := []byte(`{"data":{"something":"a","added":{"nested":[1,2]},"other":"b"}}`)
+
+	t.Run("skipped by default", func(t *testing.T) {
+		t.Parallel()
+		var res fakeRes
+		require.NoError(t, parseResponse(body, http.StatusOK, nil, &res))
+		require.Equal(t, "a", res.Something)
+	})
+
+	t.Run("disallowed", func(t *testing.T) {
+		t.Parallel()
+		c := NewClient(ClientOptions{DisallowUnknownFields: true})
+		var res fakeRes
+		err := parseResponse(body, http.StatusOK, nil, &res, c.decodeOptions()...)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `struct field for "added" doesn't exist`)
+	})
+}
+
+func TestUseNumber(t *testing.T) {
+	t.Parallel()
+	body := []byte(`{"data":{"balance":18446744073709551615}}`)
+	var res struct {
+		Balance interface{} `json:"balance" graphql:"balance"`
+	}
+
+	c := NewClient(ClientOptions{UseNumber: true, DisallowUnknownFields: true})
+	require.NoError(t, parseResponse(body, http.StatusOK, nil, &res, c.decodeOptions()...))
+	require.Equal(t, json.Number("18446744073709551615"), res.Balance)
+}
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+	parent := NewClient(ClientOptions{
+		HTTPRequestOptions: make([]HTTPRequestOption, 1, 2),
+		AllowedOperations:  map[string]bool{"GetUser": true},
+	})
+
+	clone := parent.Clone()
+	clone.HTTPRequestOptions = append(clone.HTTPRequestOptions, WithHeader("X-Clone", "1"))
+	clone.AllowedOperations["DeleteUser"] = true
+	clone.BaseURL = "http://clone"
+
+	require.Len(t, parent.HTTPRequestOptions, 1)
+	require.Nil(t, parent.HTTPRequestOptions[:2][1])
+	require.Equal(t, map[string]bool{"GetUser": true}, parent.AllowedOperations)
+	require.Empty(t, parent.BaseURL)
+	require.Len(t, clone.HTTPRequestOptions, 2)
+}
+
+func TestShouldAuthenticate(t *testing.T) {
+	t.Parallel()
+	authorized := AuthorizerFunc(func(ctx context.Context, req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer token")
+
+		return nil
+	})
+
+	tests := []struct {
+		baseURL  string
+		expected string
+	}{
+		{baseURL: "http://localhost:8080/graphql", expected: ""},
+		{baseURL: "http://127.0.0.1/graphql", expected: ""},
+		{baseURL: "http://[::1]/graphql", expected: ""},
+		{baseURL: "https://localhost/graphql", expected: "Bearer token"},
+		{baseURL: "http://api.example.com/graphql", expected: "Bearer token"},
+	}
+	for _, tt := range tests {
+		c := NewClient(ClientOptions{
+			BaseURL:              tt.baseURL,
+			Authorizers:          map[string]Authorizer{"token": authorized},
+			OperationAuthorizers: map[string]string{"GetUser": "token"},
+			ShouldAuthenticate:   AuthenticateUnlessLocal,
+		})
+		req, err := c.newRequest(context.Background(), "GetUser", "query { a }", nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, req.Header.Get("Authorization"), tt.baseURL)
+	}
+}
+
+func TestRedactVariables(t *testing.T) {
+	t.Parallel()
+
+	type credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	vars := map[string]interface{}{
+		"input":  credentials{Username: "user", Password: "secret"},
+		"tokens": []interface{}{map[string]interface{}{"apiKey": "key", "name": "a"}},
+		"Token":  "token",
+		"id":     1,
+	}
+
+	var logged map[string]interface{}
+	c := NewClient(ClientOptions{
+		OnRequest: func(operationName string, vars map[string]interface{}) {
+			logged = vars
+		},
+	})
+	c.onRequest("Login", vars)
+
+	require.Equal(t, map[string]interface{}{
+		"input":  map[string]interface{}{"username": "user", "password": RedactedValue},
+		"tokens": []interface{}{map[string]interface{}{"apiKey": RedactedValue, "name": "a"}},
+		"Token":  RedactedValue,
+		"id":     float64(1),
+	}, logged)
+	require.Equal(t, "secret", vars["input"].(credentials).Password)
+	require.Equal(t, "token", vars["Token"])
+}
+
+func TestRedactResponseFields(t *testing.T) {
+	t.Parallel()
+
+	type person struct {
+		Name string  `json:"name"`
+		SSN  *string `json:"ssn"`
+	}
+	type users struct {
+		Users []person `json:"users"`
+		Owner *person  `json:"owner"`
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.OperationName == "Failing" {
+			_, _ = w.Write([]byte(qqlSingleErr))
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"users":[{"name":"a","ssn":"123"},{"name":"b","ssn":null}],"owner":{"name":"c","ssn":"456"}}}`))
+	}))
+	defer ts.Close()
+
+	var logged []map[string]interface{}
+	var errs []error
+	c := NewClient(ClientOptions{
+		HTTPClient: ts.Client(),
+		BaseURL:    ts.URL,
+		OnResponse: func(operationName string, data map[string]interface{}, err error) {
+			logged = append(logged, data)
+			errs = append(errs, err)
+		},
+		RedactResponseFields: map[string][]string{"*": {"owner.ssn"}, "GetUsers": {"users.ssn"}},
+	})
+
+	var res users
+	require.NoError(t, c.Post(context.Background(), "GetUsers", "query GetUsers { users { name ssn } owner { name ssn } }", &res, nil))
+	require.Equal(t, "123", *res.Users[0].SSN)
+	require.Equal(t, "456", *res.Owner.SSN)
+	_, err := c.PostRaw(context.Background(), "GetOwner", "query GetOwner { users { name ssn } owner { name ssn } }", &users{}, nil)
+	require.NoError(t, err)
+	require.Error(t, c.Post(context.Background(), "Failing", "query Failing { users { name } }", &users{}, nil))
+	typed, err := PostTyped[users](context.Background(), c, "GetUsers", "query GetUsers { users { name ssn } owner { name ssn } }", nil)
+	require.NoError(t, err)
+	require.Equal(t, "123", *typed.Data.Users[0].SSN)
+	typed, err = PostTyped[users](context.Background(), c, "Failing", "query Failing { users { name } }", nil)
+	require.NoError(t, err)
+	require.True(t, typed.HasErrors())
+
+	require.Equal(t, []map[string]interface{}{
+		{
+			"users": []interface{}{
+				map[string]interface{}{"name": "a", "ssn": RedactedValue},
+				map[string]interface{}{"name": "b", "ssn": nil},
+			},
+			"owner": map[string]interface{}{"name": "c", "ssn": RedactedValue},
+		},
+		{
+			"users": []interface{}{
+				map[string]interface{}{"name": "a", "ssn": "123"},
+				map[string]interface{}{"name": "b", "ssn": nil},
+			},
+			"owner": map[string]interface{}{"name": "c", "ssn": RedactedValue},
+		},
+		nil,
+		{
+			"users": []interface{}{
+				map[string]interface{}{"name": "a", "ssn": RedactedValue},
+				map[string]interface{}{"name": "b", "ssn": nil},
+			},
+			"owner": map[string]interface{}{"name": "c", "ssn": RedactedValue},
+		},
+		nil,
+	}, logged)
+	require.NoError(t, errs[0])
+	require.Error(t, errs[2])
+	require.NoError(t, errs[3])
+	require.Error(t, errs[4])
+}
+
+func TestUpload(t *testing.T) {
+	t.Parallel()
+
+	type fileInput struct {
+		Name string `json:"name"`
+		File Upload `json:"file"`
+	}
+
+	var operations, fileMap, first, second, firstType, secondType string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		operations = r.FormValue("operations")
+		fileMap = r.FormValue("map")
+		first, firstType = readFormFile(t, r, "0")
+		second, secondType = readFormFile(t, r, "1")
+		_, _ = w.Write([]byte(`{"data":{"something":"uploaded"}}`))
+	}))
+	defer ts.Close()
+
+	var progress, totals []int64
+	c := NewClient(ClientOptions{
+		HTTPClient: ts.Client(),
+		BaseURL:    ts.URL,
+		OnUploadProgress: func(bytesSent, total int64) {
+			progress = append(progress, bytesSent)
+			totals = append(totals, total)
+		},
+	})
+
+	var res fakeRes
+	err := c.Post(context.Background(), "Upload", "mutation Upload($avatar: Upload!, $input: FileInput!) { upload }", &res, map[string]interface{}{
+		"avatar": &Upload{File: strings.NewReader("avatar"), Filename: "avatar.png", ContentType: "image/png", Size: 6},
+		"input":  fileInput{Name: "doc", File: Upload{File: strings.NewReader("hello"), Filename: "doc.txt", Size: 5}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "uploaded", res.Something)
+	require.JSONEq(t, `{"query":"mutation Upload($avatar: Upload!, $input: FileInput!) { upload }","operationName":"Upload","variables":{"avatar":null,"input":{"name":"doc","file":null}}}`, operations)
+	require.JSONEq(t, `{"0":["variables.avatar"],"1":["variables.input.file"]}`, fileMap)
+	require.Equal(t, "avatar", first)
+	require.Equal(t, "hello", second)
+	require.Equal(t, "image/png", firstType)
+	require.Equal(t, "text/plain; charset=utf-8", secondType)
+	require.Equal(t, []int64{6, 11}, progress)
+	require.Equal(t, []int64{11, 11}, totals)
+}
+
+// readFormFile returns the content and the content type of a file part
+func readFormFile(t *testing.T, r *http.Request, name string) (string, string) {
+	t.Helper()
+	file, header, err := r.FormFile(name)
+	require.NoError(t, err)
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+
+	return string(content), header.Header.Get("Content-Type")
+}
+
+func TestPostRaw(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"something":"some data"}}`))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL})
+	var res fakeRes
+	data, err := c.PostRaw(context.Background(), "GetSomething", "query GetSomething { something }", &res, nil)
+	require.NoError(t, err)
+	require.Equal(t, "some data", res.Something)
+	require.JSONEq(t, `{"something":"some data"}`, string(data))
+}
+
+func TestNullDataWithErrors(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Test") == "500" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = w.Write([]byte(`{"data":null,"errors":[{"message":"not authorized","path":["something"]}]}`))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL})
+	for _, status := range []string{"200", "500"} {
+		res := fakeRes{Something: "unchanged"}
+		data, err := c.PostRaw(context.Background(), "GetSomething", "query GetSomething { something }", &res, nil, WithHeader("X-Test", status))
+		require.Nil(t, data, status)
+		require.Equal(t, "unchanged", res.Something, status)
+
+		var errResponse *ErrorResponse
+		require.True(t, xerrors.As(err, &errResponse), status)
+		require.Equal(t, []string{"not authorized"}, UserMessages(err), status)
+		require.Len(t, errResponse.ErrorsAt("something"), 1, status)
+		require.Equal(t, status == "500", errResponse.NetworkError != nil, status)
+	}
+}
+
+func TestTransportError(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	baseURL := ts.URL + "/graphql"
+	ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: http.DefaultClient, BaseURL: baseURL})
+	err := c.Post(context.Background(), "GetUser", "query GetUser { user { id } }", &fakeRes{}, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "GetUser (POST "+baseURL+"): request failed")
+
+	var urlErr *url.Error
+	require.True(t, errors.As(err, &urlErr))
+}
+
+type lookupInput struct {
+	ID    Optional[string] `json:"id,omitzero"`
+	Email *string          `json:"email"`
+}
+
+func (i lookupInput) Validate() error {
+	set := 0
+	if i.ID.IsSet() && !i.ID.IsNull() {
+		set++
+	}
+	if i.Email != nil {
+		set++
+	}
+	if set != 1 {
+		return fmt.Errorf("lookupInput has %d fields set: %w", set, ErrOneOf)
+	}
+
+	return nil
+}
+
+// pointerLookupInput is lookupInput generated with pointer receivers
+type pointerLookupInput lookupInput
+
+func (i *pointerLookupInput) Validate() error {
+	return lookupInput(*i).Validate()
+}
+
+func TestValidateVariables(t *testing.T) {
+	t.Parallel()
+	email := "a@example.com"
+
+	require.NoError(t, validateVariables(map[string]interface{}{"by": lookupInput{ID: OptionalOf("1")}}))
+	require.NoError(t, validateVariables(map[string]interface{}{"by": &lookupInput{Email: &email}}))
+
+	err := validateVariables(map[string]interface{}{"by": []lookupInput{{ID: OptionalOf("1"), Email: &email}}})
+	require.True(t, errors.Is(err, ErrOneOf))
+	require.EqualError(t, err, "variable by: lookupInput has 2 fields set: @oneOf input must have exactly one field set")
+
+	err = validateVariables(map[string]interface{}{"input": struct{ By Optional[lookupInput] }{By: OptionalOf(lookupInput{})}})
+	require.True(t, errors.Is(err, ErrOneOf))
+
+	// the validators with a pointer receiver are called for values too
+	require.True(t, errors.Is(validateVariables(map[string]interface{}{"by": pointerLookupInput{}}), ErrOneOf))
+	require.True(t, errors.Is(validateVariables(map[string]interface{}{"by": []pointerLookupInput{{}}}), ErrOneOf))
+	require.True(t, errors.Is(validateVariables(map[string]interface{}{"by": &pointerLookupInput{}}), ErrOneOf))
+	require.NoError(t, validateVariables(map[string]interface{}{"by": pointerLookupInput{Email: &email}}))
+
+	c := NewClient(ClientOptions{})
+	err = c.Post(context.Background(), "LookupUser", "query LookupUser($by: UserLookup!) { lookupUser(by: $by) { id } }", &fakeRes{}, map[string]interface{}{"by": lookupInput{ID: OptionalNull[string]()}})
+	require.True(t, errors.Is(err, ErrOneOf))
+}
+
+func TestAccept(t *testing.T) {
+	t.Parallel()
+	var accepted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepted = append(accepted, r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "application/graphql-response+json")
+		_, _ = w.Write([]byte(`{"data":{"something":"some data"}}`))
+	}))
+	defer ts.Close()
+
+	for _, accept := range []string{"", "application/graphql-response+json"} {
+		c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL, Accept: accept})
+		var res fakeRes
+		require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &res, nil))
+		require.Equal(t, "some data", res.Something)
+	}
+	require.Equal(t, []string{DefaultAccept, "application/graphql-response+json"}, accepted)
+}
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+	c := NewClient(ClientOptions{BatchConcurrency: 2})
+
+	// batch calls fn for 10 indexes and returns the maximum number of concurrent calls
+	batch := func(ctx context.Context) int {
+		var mu sync.Mutex
+		running, maxRunning := 0, 0
+		called := make([]bool, 10)
+		c.Batch(ctx, len(called), func(ctx context.Context, i int) {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			running--
+			called[i] = true
+			mu.Unlock()
+		})
+
+		for i := range called {
+			require.True(t, called[i], "index %d", i)
+		}
+
+		return maxRunning
+	}
+
+	require.Equal(t, 2, batch(context.Background()))
+	require.Equal(t, 3, batch(WithBatchConcurrency(context.Background(), 3)))
+
+	c.Batch(context.Background(), 0, func(ctx context.Context, i int) {
+		require.Fail(t, "called without items")
+	})
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req.OperationName {
+		case "Failing":
+			_, _ = w.Write([]byte(qqlSingleErr))
+		case "Private":
+			_, _ = w.Write([]byte(`{"data":{"something":"private"},"extensions":{"cacheControl":{"maxAge":0}}}`))
+		case "Hinted":
+			_, _ = w.Write([]byte(`{"data":{"something":"hinted"},"extensions":{"cacheControl":{"maxAge":120}}}`))
+		default:
+			_, _ = w.Write([]byte(validData))
+		}
+	}))
+	defer ts.Close()
+
+	cache := NewMemoryCache()
+	now := time.Now()
+	cache.(*memoryCache).now = func() time.Time { return now }
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL, Cache: cache, CacheTTL: time.Minute})
+
+	// post sends the operation twice and returns the number of requests it made
+	post := func(operationName, query string, vars map[string]interface{}) int32 {
+		before := atomic.LoadInt32(&requests)
+		for i := 0; i < 2; i++ {
+			var res fakeRes
+			_ = c.Post(context.Background(), operationName, query, &res, vars)
+		}
+
+		return atomic.LoadInt32(&requests) - before
+	}
+
+	require.Equal(t, int32(1), post("GetSomething", "query GetSomething($id: ID) { something }", map[string]interface{}{"id": "1"}))
+	require.Equal(t, int32(1), post("GetSomething", "query GetSomething($id: ID) { something }", map[string]interface{}{"id": "2"}))
+	require.Equal(t, int32(2), post("SetSomething", "mutation SetSomething { something }", nil))
+	require.Equal(t, int32(2), post("Failing", "query Failing { something }", nil))
+	require.Equal(t, int32(2), post("Private", "query Private { something }", nil))
+	require.Equal(t, int32(1), post("Hinted", "query Hinted { something }", nil))
+
+	now = now.Add(time.Minute)
+	require.Equal(t, int32(1), post("GetSomething", "query GetSomething($id: ID) { something }", map[string]interface{}{"id": "1"}))
+	before := atomic.LoadInt32(&requests)
+	require.NoError(t, c.Post(WithBearerToken(context.Background(), "other.user"), "GetSomething", "query GetSomething($id: ID) { something }", &fakeRes{}, map[string]interface{}{"id": "1"}))
+	require.Equal(t, before+1, atomic.LoadInt32(&requests))
+	require.Equal(t, int32(0), post("Hinted", "query Hinted { something }", nil))
+
+	// the calls authorized by a per-call header do not share responses
+	postAs := func(user string) int32 {
+		before := atomic.LoadInt32(&requests)
+		require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething($id: ID) { something }", &fakeRes{}, map[string]interface{}{"id": "1"}, WithHeader("Authorization", "Bearer "+user)))
+
+		return atomic.LoadInt32(&requests) - before
+	}
+	require.Equal(t, int32(1), postAs("a"))
+	require.Equal(t, int32(1), postAs("b"))
+	require.Equal(t, int32(0), postAs("a"))
+
+	now = now.Add(time.Minute)
+	require.Equal(t, int32(1), post("Hinted", "query Hinted { something }", nil))
+
+	var res fakeRes
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething($id: ID) { something }", &res, map[string]interface{}{"id": "1"}))
+	require.Equal(t, "some data", res.Something)
+
+	// PostTyped shares the cache of Post
+	before = atomic.LoadInt32(&requests)
+	typed, err := PostTyped[fakeRes](context.Background(), c, "GetSomething", "query GetSomething($id: ID) { something }", map[string]interface{}{"id": "1"})
+	require.NoError(t, err)
+	require.Equal(t, "some data", typed.Data.Something)
+	require.Equal(t, before, atomic.LoadInt32(&requests))
+}
+
+func TestMemoryCacheSize(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	cache := NewMemoryCacheSize(2)
+	now := time.Now()
+	cache.(*memoryCache).now = func() time.Time { return now }
+	has := func(key string) bool {
+		_, ok, err := cache.Get(ctx, key)
+		require.NoError(t, err)
+
+		return ok
+	}
+
+	require.NoError(t, cache.Set(ctx, "a", []byte("a"), time.Hour))
+	require.NoError(t, cache.Set(ctx, "b", []byte("b"), time.Minute))
+	require.NoError(t, cache.Set(ctx, "a", []byte("a2"), time.Hour))
+	require.True(t, has("a"))
+	require.True(t, has("b"))
+
+	// the entry expiring first is evicted
+	require.NoError(t, cache.Set(ctx, "c", []byte("c"), 2*time.Hour))
+	require.True(t, has("a"))
+	require.False(t, has("b"))
+	require.True(t, has("c"))
+
+	// the expired entries are evicted first
+	require.NoError(t, cache.Set(ctx, "d", []byte("d"), time.Minute))
+	now = now.Add(time.Hour)
+	require.NoError(t, cache.Set(ctx, "e", []byte("e"), time.Minute))
+	require.False(t, has("a"))
+	require.True(t, has("c"))
+	require.False(t, has("d"))
+	require.True(t, has("e"))
+	require.Len(t, cache.(*memoryCache).entries, 2)
+
+	// the sizes which are not positive are the size of NewMemoryCache
+	for _, size := range []int{0, -1} {
+		require.Equal(t, maxMemoryCacheEntries, NewMemoryCacheSize(size).(*memoryCache).size)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	polls := []string{`{"n":1}`, `{"n":1}`, "", `{"n":1}`, `{"n":2}`}
+	var polled int32
+	events := Watch(ctx, time.Millisecond, func(ctx context.Context) (string, json.RawMessage, error) {
+		i := int(atomic.AddInt32(&polled, 1)) - 1
+		if i >= len(polls) {
+			return "", json.RawMessage(polls[len(polls)-1]), nil
+		}
+		if polls[i] == "" {
+			return "", nil, errors.New("poll failed")
+		}
+
+		return polls[i], json.RawMessage(polls[i]), nil
+	})
+
+	var received []WatchEvent[string]
+	for len(received) < 3 {
+		received = append(received, <-events)
+	}
+	require.Equal(t, WatchEvent[string]{Response: `{"n":1}`}, received[0])
+	require.EqualError(t, received[1].Err, "poll failed")
+	require.Equal(t, WatchEvent[string]{Response: `{"n":2}`}, received[2])
+
+	cancel()
+	for range events {
+	}
+
+	// a non positive interval is reported without polling
+	for _, interval := range []time.Duration{0, -time.Second} {
+		events := Watch(context.Background(), interval, func(ctx context.Context) (string, json.RawMessage, error) {
+			t.Fatal("fetch called")
+
+			return "", nil, nil
+		})
+		event, ok := <-events
+		require.True(t, ok)
+		require.True(t, xerrors.Is(event.Err, ErrWatchInterval), event.Err)
+		_, ok = <-events
+		require.False(t, ok)
+	}
+}
+
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestDoer(t *testing.T) {
+	t.Parallel()
+	var operation string
+	c := NewClient(ClientOptions{
+		BaseURL: "http://graphql.test",
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			var body Request
+			_ = json.NewDecoder(req.Body).Decode(&body)
+			operation = body.OperationName
+
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(validData))}, nil
+		}),
+	})
+
+	var res fakeRes
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &res, nil))
+	require.Equal(t, "GetSomething", operation)
+	require.Equal(t, "some data", res.Something)
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	t.Parallel()
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		_, _ = w.Write([]byte(validData))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL, IdempotencyKeyHeader: "Idempotency-Key"})
+	for i := 0; i < 2; i++ {
+		require.NoError(t, c.Post(context.Background(), "SetSomething", "mutation SetSomething { something }", &fakeRes{}, nil))
+	}
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil))
+	require.NoError(t, c.Post(context.Background(), "SetSomething", "mutation SetSomething { something }", &fakeRes{}, nil, WithHeader("Idempotency-Key", "mine")))
+
+	require.Len(t, keys, 4)
+	require.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, keys[0])
+	require.NotEqual(t, keys[0], keys[1])
+	require.Empty(t, keys[2])
+	require.Equal(t, "mine", keys[3])
+}
+
+func TestServerTiming(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Server-Timing", `db;dur=53.5, cache;desc="Cache, read"`)
+		w.Header().Add("Server-Timing", `app;dur=47;desc=render`)
+		_, _ = w.Write([]byte(validData))
+	}))
+	defer ts.Close()
+
+	var operation string
+	var timings []ServerTiming
+	c := NewClient(ClientOptions{
+		HTTPClient: ts.Client(),
+		BaseURL:    ts.URL,
+		OnServerTiming: func(operationName string, serverTimings []ServerTiming) {
+			operation, timings = operationName, serverTimings
+		},
+	})
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil))
+	require.Equal(t, "GetSomething", operation)
+	require.Equal(t, []ServerTiming{
+		{Name: "db", Duration: 53500 * time.Microsecond},
+		{Name: "cache", Description: "Cache, read"},
+		{Name: "app", Duration: 47 * time.Millisecond, Description: "render"},
+	}, timings)
+}
+
+func TestSchemaHash(t *testing.T) {
+	t.Parallel()
+	load := func(input string) *ast.Schema {
+		schema, err := gqlparser.LoadSchema(&ast.Source{Input: input})
+		require.Nil(t, err)
+
+		return schema
+	}
+
+	hash := SchemaHash(load("type Query { a: String b: Int }"))
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, SchemaHash(load("type Query {\n  a: String\n  b: Int\n}")))
+	require.NotEqual(t, hash, SchemaHash(load("type Query { a: String b: String }")))
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+	var got struct {
+		Settings JSON  `json:"settings"`
+		Metadata *JSON `json:"metadata"`
+	}
+	c := &Client{}
+	require.NoError(t, c.UnmarshalData([]byte(`{"settings":{"theme":{"dark":true},"tags":["a"]},"metadata":null}`), &got))
+	require.JSONEq(t, `{"theme":{"dark":true},"tags":["a"]}`, string(got.Settings))
+	require.Nil(t, got.Metadata)
+
+	var settings struct {
+		Theme struct {
+			Dark bool `json:"dark"`
+		} `json:"theme"`
+	}
+	require.NoError(t, got.Settings.Unmarshal(&settings))
+	require.True(t, settings.Theme.Dark)
+
+	b, err := json.Marshal(map[string]JSON{"set": got.Settings, "unset": nil})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"set":{"theme":{"dark":true},"tags":["a"]},"unset":null}`, string(b))
+}
+
+func TestMutationCoalesceWindow(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	sent := map[string]int{}
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		sent[fmt.Sprint(req.OperationName, req.Variables)]++
+		mu.Unlock()
+		if req.Variables["slow"] == true {
+			<-release
+		}
+		if req.Variables["fail"] == true {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = w.Write([]byte(validData))
+	}))
+	defer ts.Close()
+	count := func(operationName string, vars map[string]interface{}) int {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return sent[fmt.Sprint(operationName, vars)]
+	}
+	mutation := "mutation SetSomething { something }"
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL, MutationCoalesceWindow: time.Hour})
+	for i := 0; i < 2; i++ {
+		require.NoError(t, c.Post(context.Background(), "SetSomething", mutation, &fakeRes{}, map[string]interface{}{"a": 1}))
+	}
+	require.Equal(t, 1, count("SetSomething", map[string]interface{}{"a": 1}))
+	require.NoError(t, c.Post(context.Background(), "SetSomething", mutation, &fakeRes{}, map[string]interface{}{"a": 2}))
+	require.Equal(t, 1, count("SetSomething", map[string]interface{}{"a": 2}))
+	require.NoError(t, c.Clone().Post(context.Background(), "SetSomething", mutation, &fakeRes{}, map[string]interface{}{"a": 1}))
+	require.Equal(t, 2, count("SetSomething", map[string]interface{}{"a": 1}))
+	for _, user := range []string{"a", "b", "a"} {
+		require.NoError(t, c.Post(context.Background(), "SetSomething", mutation, &fakeRes{}, map[string]interface{}{"a": 3}, WithHeader("Authorization", "Bearer "+user)))
+	}
+	require.Equal(t, 2, count("SetSomething", map[string]interface{}{"a": 3}))
+
+	t.Run("in flight", func(t *testing.T) {
+		vars := map[string]interface{}{"slow": true}
+		var wg sync.WaitGroup
+		var res [3]fakeRes
+		var errs [3]error
+		for i := range res {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = c.Post(context.Background(), "SetSomething", mutation, &res[i], vars)
+			}(i)
+		}
+		require.Eventually(t, func() bool { return count("SetSomething", vars) == 1 }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+		for i := range res {
+			require.NoError(t, errs[i])
+			require.Equal(t, "some data", res[i].Something)
+		}
+		require.Equal(t, 1, count("SetSomething", vars))
+	})
+
+	t.Run("failures are not kept", func(t *testing.T) {
+		vars := map[string]interface{}{"fail": true}
+		for i := 0; i < 2; i++ {
+			require.Error(t, c.Post(context.Background(), "SetSomething", mutation, &fakeRes{}, vars))
+		}
+		require.Equal(t, 2, count("SetSomething", vars))
+	})
+
+	t.Run("queries and expired window", func(t *testing.T) {
+		c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL, MutationCoalesceWindow: time.Millisecond})
+		vars := map[string]interface{}{"b": 1}
+		for i := 0; i < 2; i++ {
+			require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, vars))
+		}
+		require.Equal(t, 2, count("GetSomething", vars))
+
+		require.NoError(t, c.Post(context.Background(), "SetSomething", mutation, &fakeRes{}, vars))
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, c.Post(context.Background(), "SetSomething", mutation, &fakeRes{}, vars))
+		require.Equal(t, 2, count("SetSomething", vars))
+	})
+}
+
+func TestGetQueries(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	var requests []*http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r)
+		mu.Unlock()
+		if r.Method == http.MethodGet {
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+
+				return
+			}
+		}
+		_, _ = w.Write([]byte(validData))
+	}))
+	defer ts.Close()
+	last := func() *http.Request {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return requests[len(requests)-1]
+	}
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL + "/graphql?tenant=a", GetQueries: true})
+	query := "query GetSomething($id: ID!) { something(id: $id) }"
+	vars := map[string]interface{}{"id": "1"}
+	for i := 0; i < 2; i++ {
+		res := &fakeRes{}
+		require.NoError(t, c.Post(context.Background(), "GetSomething", query, res, vars))
+		require.Equal(t, "some data", res.Something)
+	}
+	req := last()
+	require.Equal(t, http.MethodGet, req.Method)
+	require.Equal(t, "/graphql", req.URL.Path)
+	require.Equal(t, "a", req.URL.Query().Get("tenant"))
+	require.Equal(t, query, req.URL.Query().Get("query"))
+	require.Equal(t, "GetSomething", req.URL.Query().Get("operationName"))
+	require.JSONEq(t, `{"id":"1"}`, req.URL.Query().Get("variables"))
+	require.Equal(t, `"v1"`, req.Header.Get("If-None-Match"))
+
+	require.NoError(t, c.Post(context.Background(), "GetSomething", query, &fakeRes{}, map[string]interface{}{"id": "2"}))
+	require.Empty(t, last().Header.Get("If-None-Match"))
+	require.NoError(t, c.Post(context.Background(), "GetSomething", query, &fakeRes{}, vars, WithHeader("Authorization", "Bearer other")))
+	require.Empty(t, last().Header.Get("If-None-Match"))
+
+	require.NoError(t, c.Post(context.Background(), "SetSomething", "mutation SetSomething { something }", &fakeRes{}, nil))
+	require.Equal(t, http.MethodPost, last().Method)
+	require.NoError(t, c.Post(context.Background(), "GetSomething", query, &fakeRes{}, map[string]interface{}{"file": Upload{File: strings.NewReader("a")}}))
+	require.Equal(t, http.MethodPost, last().Method)
+
+	t.Run("with cache", func(t *testing.T) {
+		c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL, GetQueries: true, Cache: NewMemoryCache(), CacheTTL: time.Hour})
+		for i := 0; i < 2; i++ {
+			require.NoError(t, c.Post(context.Background(), "GetSomething", query, &fakeRes{}, map[string]interface{}{"id": "3"}))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		count := 0
+		for _, req := range requests {
+			if req.URL.Query().Get("variables") == `{"id":"3"}` {
+				count++
+			}
+		}
+		require.Equal(t, 1, count)
+	})
+}
+
+func TestErrorCodes(t *testing.T) {
+	t.Parallel()
+	err := parseResponse([]byte(`{"data":null,"errors":[{"message":"a","extensions":{"code":"NOT_FOUND"}},{"message":"b"},{"message":"c","extensions":{"code":1}},{"message":"d","extensions":{"code":"FORBIDDEN"}}]}`), http.StatusOK, nil, &fakeRes{})
+	require.Equal(t, []string{"NOT_FOUND", "FORBIDDEN"}, ErrorCodes(xerrors.Errorf("GetSomething: %w", err)))
+	require.Nil(t, ErrorCodes(xerrors.New("network down")))
+	require.Nil(t, ErrorCodes(nil))
+}
+
+func TestUserMessages(t *testing.T) {
+	t.Parallel()
+	err := parseResponse([]byte(`{"data":null,"errors":[{"message":"user not found","extensions":{"userMessage":"This account does not exist"}},{"message":"internal error"},{"message":"a","extensions":{"userMessage":"This account does not exist"}},{"message":"b","extensions":{"userMessage":1}}]}`), http.StatusOK, nil, &fakeRes{})
+	require.Equal(t, []string{"This account does not exist", "internal error", "b"}, UserMessages(xerrors.Errorf("GetSomething: %w", err)))
+	require.Nil(t, UserMessages(xerrors.New("network down")))
+	require.Nil(t, UserMessages(nil))
+}
+
+func TestErrorsByPath(t *testing.T) {
+	t.Parallel()
+	err := parseResponse([]byte(`{"data":{"user":{"name":"a","posts":[null,{"title":"b"}]}},"errors":[{"message":"no title","path":["user","posts",0,"title"]},{"message":"no post","path":["user","posts",0]},{"message":"throttled"},{"message":"no postsCount","path":["user","postsCount"]}]}`), http.StatusOK, nil, &fakeRes{})
+
+	var errResponse *ErrorResponse
+	require.True(t, xerrors.As(err, &errResponse))
+
+	byPath := errResponse.ErrorsByPath()
+	require.Len(t, byPath, 4)
+	require.Equal(t, "no title", byPath["user.posts[0].title"][0].Message)
+	require.Equal(t, "throttled", byPath[""][0].Message)
+
+	messages := func(errs gqlerror.List) []string {
+		var messages []string
+		for _, err := range errs {
+			messages = append(messages, err.Message)
+		}
+
+		return messages
+	}
+	require.Equal(t, []string{"no title", "no post"}, messages(errResponse.ErrorsAt("user.posts[0]")))
+	require.Equal(t, []string{"no title", "no post"}, messages(errResponse.ErrorsAt("user.posts")))
+	require.Equal(t, []string{"no title"}, messages(errResponse.ErrorsAt("user.posts[0].title")))
+	require.Empty(t, errResponse.ErrorsAt("user.post"))
+	require.Empty(t, errResponse.ErrorsAt("user.posts[1]"))
+	require.Len(t, errResponse.ErrorsAt("user"), 3)
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+	var got []*Request
+	transport := TransportFunc(func(ctx context.Context, req *Request) (*TransportResponse, error) {
+		got = append(got, req)
+		switch req.OperationName {
+		case "Fail":
+			return nil, xerrors.New("bus down")
+		case "Items":
+			return &TransportResponse{Body: []byte(`{"data":{"items":[1,2]}}`)}, nil
+		}
+
+		return &TransportResponse{Body: []byte(validData)}, nil
+	})
+	c := NewClient(ClientOptions{Transport: transport, AllowedOperations: map[string]bool{"GetSomething": true, "Fail": true, "Items": true, "Upload": true}})
+
+	res := &fakeRes{}
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", res, map[string]interface{}{"id": "1"}))
+	require.Equal(t, "some data", res.Something)
+	require.Equal(t, "query GetSomething { something }", got[0].Query)
+	require.Equal(t, map[string]interface{}{"id": "1"}, got[0].Variables)
+
+	err := c.Post(context.Background(), "Fail", "query Fail { something }", res, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bus down")
+
+	var items []string
+	require.NoError(t, c.PostStream(context.Background(), "Items", "query Items { items }", "items", nil, func(element json.RawMessage) error {
+		items = append(items, string(element))
+
+		return nil
+	}))
+	require.Equal(t, []string{"1", "2"}, items)
+
+	err = c.Post(context.Background(), "Upload", "mutation Upload($file: Upload!) { upload(file: $file) }", res, map[string]interface{}{"file": Upload{}})
+	require.True(t, xerrors.Is(err, ErrTransportUploads))
+	require.True(t, xerrors.Is(c.Post(context.Background(), "Other", "query Other { something }", res, nil), ErrOperationNotAllowed))
+	require.Len(t, got, 3)
+
+	// the unset Optionals are omitted from the variables given to the transport
+	lookup := lookupInput{Email: &[]string{"a@b.c"}[0]}
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething($input: LookupInput) { something }", res, map[string]interface{}{"input": lookup}))
+	require.Equal(t, map[string]interface{}{"input": map[string]interface{}{"email": "a@b.c"}}, got[3].Variables)
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	t.Parallel()
+	var inFlight, maxInFlight int32
+	var mu sync.Mutex
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		_, _ = w.Write([]byte(validData))
+	}))
+	defer ts.Close()
+	query := "query GetSomething { something }"
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL, MaxConcurrentRequests: 2})
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = c.Post(context.Background(), "GetSomething", query, &fakeRes{}, nil)
+		}(i)
+	}
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return inFlight == 2
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := c.Clone().Post(ctx, "GetSomething", query, &fakeRes{}, nil)
+	require.True(t, xerrors.Is(err, context.DeadlineExceeded), err)
+
+	failFast := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL, MaxConcurrentRequests: 1, MaxConcurrentRequestsFailFast: true})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, failFast.Post(context.Background(), "GetSomething", query, &fakeRes{}, nil))
+	}()
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return inFlight == 3
+	}, time.Second, time.Millisecond)
+	require.True(t, xerrors.Is(failFast.Post(context.Background(), "GetSomething", query, &fakeRes{}, nil), ErrTooManyRequests))
+
+	close(release)
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(3), maxInFlight)
+	require.NoError(t, failFast.Post(context.Background(), "GetSomething", query, &fakeRes{}, nil))
+}
+
+func TestAuthorizationRedacted(t *testing.T) {
+	t.Parallel()
+	const token = "s3cr3t-t0k3n-value"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = fmt.Fprintf(w, `{"errors":[{"message":"invalid header %s"}]}`, r.Header.Get("Authorization"))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL})
+	err := c.Post(WithBearerToken(context.Background(), token), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrUnauthenticated))
+	require.NotContains(t, err.Error(), token)
+	require.Contains(t, err.Error(), "invalid header Bearer "+RedactedValue)
+
+	errDump := xerrors.New("dial failed")
+	c = NewClient(ClientOptions{
+		BaseURL: ts.URL,
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, xerrors.Errorf("request %v: %w", req.Header, errDump)
+		}),
+		HTTPRequestOptions: []HTTPRequestOption{WithHeader("Authorization", "Basic "+token)},
+	})
+	err = c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil)
+	require.Error(t, err)
+	require.True(t, xerrors.Is(err, errDump))
+	require.NotContains(t, err.Error(), token)
+	require.NotContains(t, fmt.Sprintf("%+v", err), token)
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"data":{"something":%q}}`, r.Header.Get("Authorization"))
+	}))
+	defer ts.Close()
+
+	t.Setenv(EnvEndpoint, "")
+	_, err := NewClientFromEnv()
+	require.True(t, xerrors.Is(err, ErrMissingEndpoint))
+
+	t.Setenv(EnvEndpoint, ts.URL)
+	t.Setenv(EnvTimeout, "soon")
+	_, err = NewClientFromEnv()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), EnvTimeout)
+
+	t.Setenv(EnvTimeout, "5s")
+	t.Setenv(EnvToken, "env.token")
+	c, err := NewClientFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, ts.URL, c.BaseURL)
+	require.Equal(t, 5*time.Second, c.Client.(*http.Client).Timeout)
+
+	var res fakeRes
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &res, nil))
+	require.Equal(t, "Bearer env.token", res.Something)
+	require.NoError(t, c.Post(WithBearerToken(context.Background(), "user.jwt"), "GetSomething", "query GetSomething { something }", &res, nil))
+	require.Equal(t, "Bearer user.jwt", res.Something)
+}
+
+func TestChunkedErrorsOnlyResponse(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, chunk := range []string{`{"errors":[{"mess`, `age":"boom"}`, `]}`} {
+			_, _ = io.WriteString(w, chunk)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL})
+	err := c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil)
+	var errResponse *ErrorResponse
+	require.True(t, errors.As(err, &errResponse))
+	require.Nil(t, errResponse.NetworkError)
+	require.Equal(t, &gqlerror.List{{Message: "boom"}}, errResponse.GqlErrors)
+}
+
+func TestNullVariables(t *testing.T) {
+	t.Parallel()
+	type input struct {
+		Name  *string     `json:"name,omitempty"`
+		Email interface{} `json:"email,omitempty"`
+	}
+	vars := map[string]interface{}{
+		"after": Null,
+		"input": input{Email: Null},
+	}
+
+	c := NewClient(ClientOptions{TimeLayout: time.RFC3339})
+	for _, vars := range []map[string]interface{}{vars, formatTimeVariables(vars, c.TimeLayout)} {
+		body, err := c.requestBody("Op", "query Op { op }", vars)
+		require.NoError(t, err)
+		require.Contains(t, string(body), `"variables":{"after":null,"input":{"email":null}}`)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	var keys []string
+	var mu sync.Mutex
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		mu.Unlock()
+		if atomic.AddInt32(&calls, 1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+		_, _ = w.Write([]byte(validData))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL, MaxRetries: 2, RetryBackoff: time.Millisecond})
+	res := &fakeRes{}
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", res, nil))
+	require.Equal(t, "some data", res.Something)
+	require.EqualValues(t, 3, atomic.LoadInt32(&calls))
+
+	// mutations are only retried with an idempotency key, sent again by each attempt
+	err := c.Post(context.Background(), "SetSomething", "mutation SetSomething { something }", &fakeRes{}, nil)
+	require.Error(t, err)
+	require.EqualValues(t, 4, atomic.LoadInt32(&calls))
+
+	c.IdempotencyKeyHeader = "Idempotency-Key"
+	require.NoError(t, c.Post(context.Background(), "SetSomething", "mutation SetSomething { something }", &fakeRes{}, nil))
+	require.EqualValues(t, 6, atomic.LoadInt32(&calls))
+	require.NotEmpty(t, keys[4])
+	require.Equal(t, keys[4], keys[5])
+}
+
+func TestRetryBudget(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{
+		HTTPClient:   ts.Client(),
+		BaseURL:      ts.URL,
+		MaxRetries:   3,
+		RetryBackoff: time.Millisecond,
+		RetryBudget:  NewRetryBudget(0.5, 2),
+	})
+
+	// the first call spends the burst of 2 retries, then every other call earns one
+	for i := 0; i < 5; i++ {
+		require.Error(t, c.Clone().Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil))
+	}
+	require.EqualValues(t, 5+2+2, atomic.LoadInt32(&calls))
+
+	// the requests rejected by the circuit breaker are not sent and spend no retry
+	budget := NewRetryBudget(0, 2)
+	c.RetryBudget = budget
+	c.CircuitBreaker = breakerFunc(func(req func() (interface{}, error)) (interface{}, error) {
+		return nil, xerrors.New("too many requests")
+	})
+	for i := 0; i < 3; i++ {
+		require.True(t, xerrors.Is(c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil), ErrCircuitOpen))
+	}
+	c.CircuitBreaker = NewCircuitBreaker(1, time.Minute)
+	for i := 0; i < 3; i++ {
+		require.Error(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil))
+	}
+	// only the failure of the first call, which opened the circuit, spent a retry
+	require.EqualValues(t, 5+2+2+1, atomic.LoadInt32(&calls))
+	require.EqualValues(t, 1, budget.tokens)
+}
+
+func TestRetrySendError(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	c := NewClient(ClientOptions{
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return nil, xerrors.New("connection reset")
+			}
+
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(validData))}, nil
+		}),
+		BaseURL:      "http://localhost",
+		MaxRetries:   1,
+		RetryBackoff: time.Millisecond,
+	})
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil))
+	require.EqualValues(t, 2, atomic.LoadInt32(&calls))
+
+	// the errors of the circuit breaker are not retried
+	atomic.StoreInt32(&calls, 0)
+	c.CircuitBreaker = NewCircuitBreaker(1, time.Minute)
+	c.Client = doerFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+
+		return nil, xerrors.New("connection reset")
+	})
+	err := c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil)
+	require.True(t, xerrors.Is(err, ErrCircuitOpen))
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	// nor the rejections of the other breakers, such as gobreaker's ErrOpenState
+	var executions int32
+	c.CircuitBreaker = breakerFunc(func(req func() (interface{}, error)) (interface{}, error) {
+		atomic.AddInt32(&executions, 1)
+
+		return nil, xerrors.New("circuit breaker is open")
+	})
+	err = c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil)
+	require.True(t, xerrors.Is(err, ErrCircuitOpen), err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&executions))
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+// breakerFunc is a CircuitBreaker calling the function
+type breakerFunc func(req func() (interface{}, error)) (interface{}, error)
+
+func (f breakerFunc) Execute(req func() (interface{}, error)) (interface{}, error) {
+	return f(req)
+}
+
+func TestCallOverrides(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-Test") {
+		case "slow":
+			time.Sleep(100 * time.Millisecond)
+		case "flaky":
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+		}
+		_, _ = w.Write([]byte(validData))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL, RequestTimeout: 10 * time.Millisecond, RetryBackoff: time.Millisecond})
+
+	err := c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil, WithHeader("X-Test", "slow"))
+	require.True(t, xerrors.Is(err, context.DeadlineExceeded))
+	ctx := WithRequestTimeout(context.Background(), time.Second)
+	require.NoError(t, c.Post(ctx, "GetSomething", "query GetSomething { something }", &fakeRes{}, nil, WithHeader("X-Test", "slow")))
+
+	require.Error(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil, WithHeader("X-Test", "flaky")))
+	atomic.StoreInt32(&calls, 0)
+	ctx = WithMaxRetries(context.Background(), 1)
+	require.NoError(t, c.Post(ctx, "GetSomething", "query GetSomething { something }", &fakeRes{}, nil, WithHeader("X-Test", "flaky")))
+	require.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
+
+func TestRedirectPolicy(t *testing.T) {
+	t.Parallel()
+	var authorization string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(validData))
+	}))
+	defer target.Close()
+	var origin *httptest.Server
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, target.URL, http.StatusPermanentRedirect)
+		case "/here":
+			http.Redirect(w, r, origin.URL+"/graphql", http.StatusPermanentRedirect)
+		default:
+			authorization = r.Header.Get("Authorization")
+			_, _ = w.Write([]byte(validData))
+		}
+	}))
+	defer origin.Close()
+
+	post := func(policy RedirectPolicy, path string) error {
+		authorization = ""
+		c := NewClient(ClientOptions{
+			BaseURL:            origin.URL + path,
+			RedirectPolicy:     policy,
+			HTTPRequestOptions: []HTTPRequestOption{WithHeader("Authorization", "Bearer secret-token")},
+		})
+
+		return c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil)
+	}
+
+	err := post(RedirectNone, "/moved")
+	require.True(t, xerrors.Is(err, ErrRedirect))
+	require.Contains(t, err.Error(), target.URL)
+	require.Empty(t, authorization)
+
+	require.True(t, xerrors.Is(post(RedirectSameHost, "/moved"), ErrRedirect))
+	require.NoError(t, post(RedirectSameHost, "/here"))
+	require.Equal(t, "Bearer secret-token", authorization)
+
+	require.NoError(t, post(RedirectKeepAuthorization, "/moved"))
+	require.Equal(t, "Bearer secret-token", authorization)
+}
+
+func TestOperationNameHeader(t *testing.T) {
+	t.Parallel()
+	var names []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		names = append(names, r.Header.Get("X-GraphQL-Operation"))
+		_, _ = w.Write([]byte(validData))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL, OperationNameHeader: "X-GraphQL-Operation"})
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil))
+	c.GetQueries = true
+	require.NoError(t, c.Post(context.Background(), "GetOther", "query GetOther { something }", &fakeRes{}, nil))
+	require.NoError(t, c.Post(context.Background(), "SetSomething", "mutation SetSomething { something }", &fakeRes{}, nil, WithHeader("X-GraphQL-Operation", "custom")))
+	c.OperationNameHeader = ""
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil))
+
+	require.Equal(t, []string{"GetSomething", "GetOther", "custom", ""}, names)
+}
+
+func TestSendEmptyVariables(t *testing.T) {
+	t.Parallel()
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(body)+r.URL.Query().Get("variables"))
+		_, _ = w.Write([]byte(validData))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL})
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil))
+	c.SendEmptyVariables = true
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil))
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, map[string]interface{}{"id": 1}))
+	c.GetQueries = true
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil))
+
+	require.Equal(t, []string{
+		`{"query":"query GetSomething { something }","operationName":"GetSomething"}`,
+		`{"query":"query GetSomething { something }","variables":{},"operationName":"GetSomething"}`,
+		`{"query":"query GetSomething { something }","variables":{"id":1},"operationName":"GetSomething"}`,
+		`{}`,
+	}, requests)
+}
+
+func TestPrefetch(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.OperationName == "Failing" {
+			_, _ = w.Write([]byte(qqlSingleErr))
+
+			return
+		}
+		_, _ = w.Write([]byte(validData))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL, Cache: NewMemoryCache(), CacheTTL: time.Minute})
+	require.NoError(t, c.Prefetch(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil))
+	require.NoError(t, c.Prefetch(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil))
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	var res fakeRes
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &res, nil))
+	require.Equal(t, "some data", res.Something)
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	var errResponse *ErrorResponse
+	require.True(t, xerrors.As(c.Prefetch(context.Background(), "Failing", "query Failing { something }", &fakeRes{}, nil), &errResponse))
+	require.True(t, xerrors.Is(c.Prefetch(context.Background(), "SetSomething", "mutation SetSomething { something }", &fakeRes{}, nil), ErrNotCacheable))
+	c.Cache = nil
+	require.True(t, xerrors.Is(c.Prefetch(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil), ErrNotCacheable))
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestGraphQLResponseMediaType(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		status := http.StatusBadRequest
+		switch req.OperationName {
+		case "Spec":
+			w.Header().Set("Content-Type", "application/graphql-response+json; charset=utf-8")
+		case "SpecUnauthenticated":
+			w.Header().Set("Content-Type", GraphQLResponseMediaType)
+			status = http.StatusUnauthorized
+		case "SpecNotGraphQL":
+			w.Header().Set("Content-Type", GraphQLResponseMediaType)
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"message":"bad gateway"}`))
+
+			return
+		default:
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(qqlSingleErr))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL})
+	post := func(operationName string) *ErrorResponse {
+		var errResponse *ErrorResponse
+		require.True(t, xerrors.As(c.Post(context.Background(), operationName, "query "+operationName+" { something }", &fakeRes{}, nil), &errResponse))
+
+		return errResponse
+	}
+
+	spec := post("Spec")
+	require.Equal(t, &HTTPError{Code: http.StatusBadRequest, Message: "Bad Request"}, spec.NetworkError)
+	require.Len(t, *spec.GqlErrors, 1)
+
+	unauthenticated := post("SpecUnauthenticated")
+	require.True(t, xerrors.Is(unauthenticated, ErrUnauthenticated))
+	require.Len(t, *unauthenticated.GqlErrors, 1)
+
+	notGraphQL := post("SpecNotGraphQL")
+	require.Equal(t, `Response body {"message":"bad gateway"}`, notGraphQL.NetworkError.Message)
+	require.Nil(t, notGraphQL.GqlErrors)
+
+	legacy := post("Legacy")
+	require.Equal(t, "Response body "+qqlSingleErr, legacy.NetworkError.Message)
+	require.Len(t, *legacy.GqlErrors, 1)
+
+	resp, err := PostTyped[fakeRes](context.Background(), c, "Spec", "query Spec { something }", nil)
+	require.Equal(t, "Bad Request", err.(*ErrorResponse).NetworkError.Message)
+	require.Len(t, resp.Errors, 1)
+}
+
+func TestErrorResponsePrecedence(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"errors":[{"message":"token expired","extensions":{"code":"UNAUTHENTICATED"}}]}`))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL})
+	err := xerrors.Errorf("wrapped: %w", c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil))
+
+	var errResponse ErrorResponse
+	require.True(t, errors.As(err, &errResponse))
+	require.Equal(t, http.StatusUnauthorized, errResponse.NetworkError.Code)
+	require.Len(t, *errResponse.GqlErrors, 1)
+	var errResponsePtr *ErrorResponse
+	require.True(t, xerrors.As(err, &errResponsePtr))
+	require.Equal(t, errResponse, *errResponsePtr)
+
+	var httpErr *HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	require.Equal(t, http.StatusUnauthorized, httpErr.Code)
+	var gqlErr *gqlerror.Error
+	require.True(t, xerrors.As(err, &gqlErr))
+	require.Equal(t, "token expired", gqlErr.Message)
+	var gqlErrs gqlerror.List
+	require.True(t, errors.As(err, &gqlErrs))
+	require.Len(t, gqlErrs, 1)
+
+	require.True(t, errors.Is(err, ErrUnauthenticated))
+	require.True(t, xerrors.Is(err, ErrUnauthenticated))
+	require.Equal(t, errResponsePtr.NetworkError, errResponsePtr.Unwrap())
+	require.True(t, strings.HasPrefix(errResponsePtr.Error(), `{"networkErrors":`))
+
+	c.ErrorPrecedence = GqlErrorsFirst
+	err = c.Post(context.Background(), "GetSomething", "query GetSomething { something }", &fakeRes{}, nil)
+	require.True(t, xerrors.As(err, &errResponsePtr))
+	require.Equal(t, (*errResponsePtr.GqlErrors)[0], errResponsePtr.Unwrap())
+	require.True(t, strings.HasPrefix(err.Error(), `{"graphqlErrors":`))
+	require.True(t, xerrors.Is(err, ErrUnauthenticated))
+
+	require.Nil(t, (&ErrorResponse{}).Unwrap())
+	require.False(t, errors.As(&ErrorResponse{NetworkError: &HTTPError{Code: 500}}, &gqlErrs))
+}
+
+// rotatingCredentials returns the access keys AKID1, AKID2... at each retrieval
+type rotatingCredentials struct {
+	retrieved int
+	expired   bool
+}
+
+func (p *rotatingCredentials) Retrieve() (credentials.Value, error) {
+	p.retrieved++
+	p.expired = false
+
+	return credentials.Value{AccessKeyID: fmt.Sprintf("AKID%d", p.retrieved), SecretAccessKey: "secret"}, nil
+}
+
+func (p *rotatingCredentials) IsExpired() bool {
+	return p.expired
+}
+
+func TestCognitoCredentialsRefresh(t *testing.T) {
+	t.Parallel()
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(strings.Split(r.Header.Get("Authorization"), "/")[0], "AWS4-HMAC-SHA256 Credential=")
+		keys = append(keys, key)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		if key == "AKID1" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ExpiredTokenException","message":"The security token included in the request is expired"}`))
+
+			return
+		}
+		_, _ = w.Write([]byte(`{"AuthenticationResult":{"IdToken":"id-token"}}`))
+	}))
+	defer ts.Close()
+
+	provider := &rotatingCredentials{}
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:    aws.String(ts.URL),
+		Region:      aws.String("eu-west-1"),
+		Credentials: credentials.NewCredentials(provider),
+		MaxRetries:  aws.Int(0),
+	})
+	require.NoError(t, err)
+	c := NewClient(ClientOptions{AuthorizationOptions: ClientAuthorizationOptions{Session: sess, ClientID: "client", UserPoolID: "pool"}})
+
+	authorize := func() string {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+		require.NoError(t, c.Authorization.Authorize(context.Background(), req))
+
+		return req.Header.Get("Authorization")
+	}
+
+	require.Equal(t, "Bearer id-token", authorize())
+	require.Equal(t, []string{"AKID1", "AKID2"}, keys)
+	require.Equal(t, "Bearer id-token", authorize())
+	provider.expired = true
+	require.Equal(t, "Bearer id-token", authorize())
+	require.Equal(t, []string{"AKID1", "AKID2", "AKID2", "AKID3"}, keys)
+}
+
+func TestWithCognitoPool(t *testing.T) {
+	t.Parallel()
+	var logins []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			UserPoolID     string `json:"UserPoolId"`
+			ClientID       string `json:"ClientId"`
+			AuthParameters map[string]string
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+		logins = append(logins, input.UserPoolID+" "+input.ClientID+" "+input.AuthParameters["USERNAME"])
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		_, _ = w.Write([]byte(`{"AuthenticationResult":{"IdToken":"` + input.UserPoolID + `-token"}}`))
+	}))
+	defer ts.Close()
+
+	sess, err := session.NewSession(&aws.Config{
+		Endpoint:    aws.String(ts.URL),
+		Region:      aws.String("eu-west-1"),
+		Credentials: credentials.NewStaticCredentials("AKID", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	})
+	require.NoError(t, err)
+	c := NewClient(ClientOptions{AuthorizationOptions: ClientAuthorizationOptions{Session: sess, ClientID: "client", UserPoolID: "pool", Username: "admin"}})
+
+	authorize := func(ctx context.Context) string {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+		require.NoError(t, c.Authorization.Authorize(ctx, req))
+
+		return req.Header.Get("Authorization")
+	}
+
+	require.Equal(t, "Bearer pool-token", authorize(context.Background()))
+	tenant := WithCognitoPool(context.Background(), CognitoPool{UserPoolID: "tenant-pool", ClientID: "tenant-client"})
+	require.Equal(t, "Bearer tenant-pool-token", authorize(tenant))
+	require.Equal(t, []string{"pool client admin", "tenant-pool tenant-client admin"}, logins)
+
+	key, err := c.cacheKey(context.Background(), "Op", "query Op { a }", nil, nil)
+	require.NoError(t, err)
+	tenantKey, err := c.cacheKey(tenant, "Op", "query Op { a }", nil, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, key, tenantKey)
+}
@@ -0,0 +1,79 @@
+// Package clienttest provides helpers to test the code sending operations with the client package.
+package clienttest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/perchcredit/gqlgenc/client"
+)
+
+// emptyResponse is the response of the requests when no Respond function is set
+const emptyResponse = `{"data":{}}`
+
+// RecordingTransport is a client.Transport recording the requests it receives, to assert the operations
+// sent and their variables. Set it as the ClientOptions.Transport of the client under test.
+type RecordingTransport struct {
+	// Respond returns the response of a request, an empty data object when nil
+	Respond func(ctx context.Context, req *client.Request) (*client.TransportResponse, error)
+
+	mu       sync.Mutex
+	requests []client.Request
+}
+
+var _ client.Transport = (*RecordingTransport)(nil)
+
+// Do records the request and returns the response of Respond
+func (t *RecordingTransport) Do(ctx context.Context, req *client.Request) (*client.TransportResponse, error) {
+	recorded := *req
+	if req.Variables != nil {
+		recorded.Variables = make(map[string]interface{}, len(req.Variables))
+		for name, value := range req.Variables {
+			recorded.Variables[name] = value
+		}
+	}
+
+	t.mu.Lock()
+	t.requests = append(t.requests, recorded)
+	t.mu.Unlock()
+
+	if t.Respond == nil {
+		return &client.TransportResponse{Body: []byte(emptyResponse)}, nil
+	}
+
+	return t.Respond(ctx, req)
+}
+
+// Requests returns the recorded requests in the order they were received
+func (t *RecordingTransport) Requests() []client.Request {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]client.Request(nil), t.requests...)
+}
+
+// OperationNames returns the operation names of the recorded requests in the order they were received
+func (t *RecordingTransport) OperationNames() []string {
+	requests := t.Requests()
+	names := make([]string, len(requests))
+	for i, req := range requests {
+		names[i] = req.OperationName
+	}
+
+	return names
+}
+
+// Reset forgets the recorded requests
+func (t *RecordingTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.requests = nil
+}
+
+// RespondWith returns a Respond function answering every request with body, a GraphQL response
+func RespondWith(body string) func(ctx context.Context, req *client.Request) (*client.TransportResponse, error) {
+	return func(ctx context.Context, req *client.Request) (*client.TransportResponse, error) {
+		return &client.TransportResponse{Body: []byte(body)}, nil
+	}
+}
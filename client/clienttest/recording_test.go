@@ -0,0 +1,35 @@
+package clienttest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/perchcredit/gqlgenc/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingTransport(t *testing.T) {
+	t.Parallel()
+	transport := &RecordingTransport{}
+	c := client.NewClient(client.ClientOptions{Transport: transport})
+
+	var res struct {
+		Something string `graphql:"something"`
+	}
+	vars := map[string]interface{}{"id": "1"}
+	require.NoError(t, c.Post(context.Background(), "GetSomething", "query GetSomething($id: ID!) { something }", &res, vars))
+	vars["id"] = "changed"
+
+	transport.Respond = RespondWith(`{"data":{"something":"recorded"}}`)
+	require.NoError(t, c.Post(context.Background(), "GetOther", "query GetOther { something }", &res, nil))
+	require.Equal(t, "recorded", res.Something)
+
+	require.Equal(t, []client.Request{
+		{Query: "query GetSomething($id: ID!) { something }", Variables: map[string]interface{}{"id": "1"}, OperationName: "GetSomething"},
+		{Query: "query GetOther { something }", OperationName: "GetOther"},
+	}, transport.Requests())
+	require.Equal(t, []string{"GetSomething", "GetOther"}, transport.OperationNames())
+
+	transport.Reset()
+	require.Empty(t, transport.Requests())
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// mutationCalls are the mutation calls identical calls join during Client.MutationCoalesceWindow
+type mutationCalls struct {
+	mu    sync.Mutex
+	calls map[string]*mutationCall
+}
+
+// mutationCall is the first of identical mutation calls, its result is set once done is closed
+type mutationCall struct {
+	done       chan struct{}
+	body       []byte
+	statusCode int
+	header     http.Header
+	err        error
+}
+
+// coalescedDo is do returning the result of an identical mutation call still in flight or sent less than
+// MutationCoalesceWindow ago, calls are identical when they have the same operation, variables, bearer token and
+// headers set by their per-call options
+func (c *Client) coalescedDo(ctx context.Context, operationName, query string, vars map[string]interface{}, httpRequestOptions []HTTPRequestOption) ([]byte, int, http.Header, error) {
+	if c.MutationCoalesceWindow <= 0 || c.mutationCalls == nil {
+		return c.do(ctx, operationName, query, vars, httpRequestOptions)
+	}
+
+	key, err := c.cacheKey(ctx, operationName, query, vars, httpRequestOptions)
+	if err != nil {
+		return c.do(ctx, operationName, query, vars, httpRequestOptions)
+	}
+
+	c.mutationCalls.mu.Lock()
+	if call, ok := c.mutationCalls.calls[key]; ok {
+		c.mutationCalls.mu.Unlock()
+
+		select {
+		case <-call.done:
+			return call.body, call.statusCode, call.header, call.err
+		case <-ctx.Done():
+			return nil, 0, nil, ctx.Err()
+		}
+	}
+
+	call := &mutationCall{done: make(chan struct{})}
+	c.mutationCalls.calls[key] = call
+	c.mutationCalls.mu.Unlock()
+
+	sentAt := time.Now()
+	call.body, call.statusCode, call.header, call.err = c.do(ctx, operationName, query, vars, httpRequestOptions)
+	close(call.done)
+
+	forget := func() {
+		c.mutationCalls.mu.Lock()
+		defer c.mutationCalls.mu.Unlock()
+
+		if c.mutationCalls.calls[key] == call {
+			delete(c.mutationCalls.calls, key)
+		}
+	}
+
+	// failed calls are forgotten right away so that they can be retried
+	remaining := c.MutationCoalesceWindow - time.Since(sentAt)
+	if call.err != nil || call.statusCode != http.StatusOK || remaining <= 0 {
+		forget()
+	} else {
+		time.AfterFunc(remaining, forget)
+	}
+
+	return call.body, call.statusCode, call.header, call.err
+}
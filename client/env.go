@@ -0,0 +1,78 @@
+package client
+
+import (
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"golang.org/x/xerrors"
+)
+
+// Environment variables read by OptionsFromEnv
+const (
+	// EnvEndpoint is the URL of the GraphQL endpoint, required
+	EnvEndpoint = "GRAPHQL_ENDPOINT"
+	// EnvToken is a static bearer token sent with the requests
+	EnvToken = "GRAPHQL_TOKEN"
+	// EnvTimeout is the timeout of the requests, a time.ParseDuration string such as 10s
+	EnvTimeout = "GRAPHQL_TIMEOUT"
+	// EnvCognitoClientID and EnvCognitoUserPoolID enable the cognito authorization, the AWS session is
+	// configured by the usual AWS environment variables
+	EnvCognitoClientID   = "GRAPHQL_COGNITO_CLIENT_ID"
+	EnvCognitoUserPoolID = "GRAPHQL_COGNITO_USER_POOL_ID"
+	// EnvUsername and EnvPassword are the cognito credentials
+	EnvUsername = "GRAPHQL_USERNAME"
+	EnvPassword = "GRAPHQL_PASSWORD"
+)
+
+// ErrMissingEndpoint is returned by OptionsFromEnv when EnvEndpoint is not set
+var ErrMissingEndpoint = xerrors.New(EnvEndpoint + " is not set")
+
+// OptionsFromEnv returns the options configured by the GRAPHQL_* environment variables, so that an application
+// configures its client without code changes
+func OptionsFromEnv() (ClientOptions, error) {
+	endpoint := os.Getenv(EnvEndpoint)
+	if endpoint == "" {
+		return ClientOptions{}, ErrMissingEndpoint
+	}
+
+	var timeout time.Duration
+	if value := os.Getenv(EnvTimeout); value != "" {
+		var err error
+		if timeout, err = time.ParseDuration(value); err != nil {
+			return ClientOptions{}, xerrors.Errorf("%s: %w", EnvTimeout, err)
+		}
+	}
+
+	options := ClientOptions{
+		HTTPClient: NewHTTPClient(timeout, RedirectNone),
+		BaseURL:    endpoint,
+		AuthorizationOptions: ClientAuthorizationOptions{
+			ClientID:   os.Getenv(EnvCognitoClientID),
+			UserPoolID: os.Getenv(EnvCognitoUserPoolID),
+			Username:   os.Getenv(EnvUsername),
+			Password:   os.Getenv(EnvPassword),
+			Token:      os.Getenv(EnvToken),
+		},
+	}
+
+	if options.AuthorizationOptions.ClientID != "" && options.AuthorizationOptions.UserPoolID != "" {
+		sess, err := session.NewSession()
+		if err != nil {
+			return ClientOptions{}, xerrors.Errorf("create aws session: %w", err)
+		}
+		options.AuthorizationOptions.Session = sess
+	}
+
+	return options, nil
+}
+
+// NewClientFromEnv creates a client configured by the environment variables read by OptionsFromEnv
+func NewClientFromEnv() (*Client, error) {
+	options, err := OptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(options), nil
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// getRequest creates a GET request encoding the query, its operation name and variables in the URL
+// following the GraphQL over HTTP specification
+func (c *Client) getRequest(ctx context.Context, operationName, query string, vars map[string]interface{}) (*http.Request, error) {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return nil, xerrors.Errorf("parse base url: %w", err)
+	}
+
+	params := u.Query()
+	params.Set("query", query)
+	if operationName != "" {
+		params.Set("operationName", operationName)
+	}
+	if len(vars) > 0 {
+		variables, err := json.Marshal(vars)
+		if err != nil {
+			return nil, xerrors.Errorf("encode: %w", err)
+		}
+		params.Set("variables", string(variables))
+	} else if c.SendEmptyVariables {
+		params.Set("variables", "{}")
+	}
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, xerrors.Errorf("create request struct failed: %w", err)
+	}
+
+	return req, nil
+}
+
+// maxETags is the number of responses kept to revalidate them with their ETag
+const maxETags = 1024
+
+// etagStore holds the last response carrying an ETag of the queries by cache key
+type etagStore struct {
+	mu      sync.Mutex
+	entries map[string]etagEntry
+}
+
+type etagEntry struct {
+	etag string
+	body []byte
+}
+
+func newETagStore() *etagStore {
+	return &etagStore{entries: make(map[string]etagEntry)}
+}
+
+func (s *etagStore) load(key string) (etagEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+
+	return entry, ok
+}
+
+// store records the response of key, evicting an arbitrary one when the store is full
+func (s *etagStore) store(key string, entry etagEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[key]; !ok && len(s.entries) >= maxETags {
+		for evicted := range s.entries {
+			delete(s.entries, evicted)
+
+			break
+		}
+	}
+	s.entries[key] = entry
+}
+
+// conditionalDo is do revalidating the last response of a query sent as a GET request with its ETag,
+// the stored response is returned when the server answers 304 Not Modified
+func (c *Client) conditionalDo(ctx context.Context, key, operationName, query string, vars map[string]interface{}, httpRequestOptions []HTTPRequestOption) ([]byte, int, http.Header, error) {
+	if !c.GetQueries || c.etags == nil {
+		return c.do(ctx, operationName, query, vars, httpRequestOptions)
+	}
+
+	entry, ok := c.etags.load(key)
+	if ok {
+		// prepended so that the options of the caller can override it
+		httpRequestOptions = append([]HTTPRequestOption{WithHeader("If-None-Match", entry.etag)}, httpRequestOptions...)
+	}
+
+	body, statusCode, header, err := c.do(ctx, operationName, query, vars, httpRequestOptions)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	if statusCode == http.StatusNotModified && ok {
+		return entry.body, http.StatusOK, nil, nil
+	}
+
+	if etag := header.Get("ETag"); statusCode == http.StatusOK && etag != "" {
+		c.etags.store(key, etagEntry{etag: etag, body: body})
+	}
+
+	return body, statusCode, header, nil
+}
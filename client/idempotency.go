@@ -0,0 +1,42 @@
+package client
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/xerrors"
+)
+
+// newUUID returns a random version 4 UUID
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// idempotencyKey returns the options adding an idempotency key to the requests of a mutation call,
+// the key is generated once per call so every attempt of the call sends the same one
+func (c *Client) idempotencyKey(operationName, query string, httpRequestOptions []HTTPRequestOption) ([]HTTPRequestOption, error) {
+	if c.IdempotencyKeyHeader == "" || operationType(operationName, query) != ast.Mutation {
+		return httpRequestOptions, nil
+	}
+
+	key, err := newUUID()
+	if err != nil {
+		return nil, xerrors.Errorf("generate idempotency key: %w", err)
+	}
+
+	header := c.IdempotencyKeyHeader
+
+	// the options given by the caller are applied last and may set their own key
+	return append([]HTTPRequestOption{func(req *http.Request) {
+		req.Header.Set(header, key)
+	}}, httpRequestOptions...), nil
+}
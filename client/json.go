@@ -0,0 +1,30 @@
+package client
+
+import (
+	"encoding/json"
+)
+
+// JSON holds an arbitrary JSON value verbatim, map a JSON scalar to this type to decode it later
+// into the Go type of your choice
+type JSON json.RawMessage
+
+// MarshalJSON encodes the value as is, an empty value as null
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+
+	return j, nil
+}
+
+// UnmarshalJSON stores a copy of data
+func (j *JSON) UnmarshalJSON(data []byte) error {
+	*j = append((*j)[:0], data...)
+
+	return nil
+}
+
+// Unmarshal decodes the value into v
+func (j JSON) Unmarshal(v interface{}) error {
+	return json.Unmarshal(j, v)
+}
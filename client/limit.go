@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// ErrTooManyRequests is returned when MaxConcurrentRequests requests are already in flight
+// and MaxConcurrentRequestsFailFast is set
+var ErrTooManyRequests = xerrors.New("too many concurrent requests")
+
+// acquireRequestSlot waits for one of the MaxConcurrentRequests slots, or fails fast when none is free and
+// MaxConcurrentRequestsFailFast is set, the returned release frees it
+func (c *Client) acquireRequestSlot(ctx context.Context, operationName string) (release func(), err error) {
+	if c.requestSlots == nil {
+		return func() {}, nil
+	}
+
+	if c.MaxConcurrentRequestsFailFast {
+		select {
+		case c.requestSlots <- struct{}{}:
+		default:
+			return nil, xerrors.Errorf("%s: %w", operationName, ErrTooManyRequests)
+		}
+	} else {
+		select {
+		case c.requestSlots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, xerrors.Errorf("%s: waiting for a request slot: %w", operationName, ctx.Err())
+		}
+	}
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() { <-c.requestSlots })
+	}, nil
+}
+
+// releaseOnClose frees the request slot of a response once its body is closed
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	defer r.release()
+
+	return r.ReadCloser.Close()
+}
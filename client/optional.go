@@ -0,0 +1,117 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+type optionalState uint8
+
+const (
+	optionalUnset optionalState = iota
+	optionalNull
+	optionalSet
+)
+
+// Optional is a nullable input value which distinguishes an omitted value from an explicit null.
+//
+// Used in a struct field tagged with omitzero, an unset Optional is omitted from the JSON and the variables
+// sent by the client, an explicit null marshals as null and a set value marshals as the value itself.
+type Optional[T any] struct {
+	value T
+	state optionalState
+}
+
+// OptionalOf returns an Optional set to v
+func OptionalOf[T any](v T) Optional[T] {
+	return Optional[T]{value: v, state: optionalSet}
+}
+
+// OptionalNull returns an Optional explicitly set to null
+func OptionalNull[T any]() Optional[T] {
+	return Optional[T]{state: optionalNull}
+}
+
+// Set sets the value
+func (o *Optional[T]) Set(v T) {
+	o.value = v
+	o.state = optionalSet
+}
+
+// Null sets the value to an explicit null
+func (o *Optional[T]) Null() {
+	var zero T
+	o.value = zero
+	o.state = optionalNull
+}
+
+// Unset resets the value so it is omitted
+func (o *Optional[T]) Unset() {
+	var zero T
+	o.value = zero
+	o.state = optionalUnset
+}
+
+// Get returns the value and whether it is set
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.state == optionalSet
+}
+
+// Value returns the value, the zero value when it is unset or null
+func (o Optional[T]) Value() T {
+	return o.value
+}
+
+// IsSet returns true when a value is set
+func (o Optional[T]) IsSet() bool {
+	return o.state == optionalSet
+}
+
+// IsNull returns true when the value is explicitly null
+func (o Optional[T]) IsNull() bool {
+	return o.state == optionalNull
+}
+
+// optional marks the Optional types for hasOptionals
+func (o Optional[T]) optional() {}
+
+// IsZero returns true when the value is unset, it is used by omitzero to omit the field
+func (o Optional[T]) IsZero() bool {
+	return o.state == optionalUnset
+}
+
+// MarshalJSON marshals the value, or null when it is not set
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if o.state != optionalSet {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(o.value)
+}
+
+// Validate validates the value when it is set, so the inputs nested in optional fields are validated
+func (o Optional[T]) Validate() error {
+	if o.state != optionalSet {
+		return nil
+	}
+
+	return validateValue(reflect.ValueOf(o.value))
+}
+
+// UnmarshalJSON sets the value, or marks it as null
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		o.Null()
+
+		return nil
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	o.Set(v)
+
+	return nil
+}
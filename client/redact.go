@@ -0,0 +1,189 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// RedactedValue replaces the values of the redacted variables
+const RedactedValue = "[REDACTED]"
+
+// SensitiveVariableNames are the variable and input field names, compared case insensitively,
+// whose values DefaultRedactVariables masks
+var SensitiveVariableNames = []string{"password", "secret", "token", "accessToken", "refreshToken", "apiKey", "authorization"}
+
+// DefaultRedactVariables returns a copy of vars with the values named in SensitiveVariableNames masked,
+// input objects and lists included, vars is left untouched
+func DefaultRedactVariables(vars map[string]interface{}) map[string]interface{} {
+	if len(vars) == 0 {
+		return vars
+	}
+
+	// input objects are structs, redact their JSON form
+	content, err := json.Marshal(vars)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	var redacted map[string]interface{}
+	if err := json.Unmarshal(content, &redacted); err != nil {
+		return map[string]interface{}{}
+	}
+
+	redactValue(redacted)
+
+	return redacted
+}
+
+func redactValue(value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for name, v := range value {
+			if isSensitiveVariable(name) {
+				value[name] = RedactedValue
+
+				continue
+			}
+			redactValue(v)
+		}
+	case []interface{}:
+		for _, v := range value {
+			redactValue(v)
+		}
+	}
+}
+
+func isSensitiveVariable(name string) bool {
+	for _, sensitive := range SensitiveVariableNames {
+		if strings.EqualFold(name, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// onRequest calls the OnRequest hook with the redacted variables
+func (c *Client) onRequest(operationName string, vars map[string]interface{}) {
+	if c.OnRequest == nil {
+		return
+	}
+
+	redact := c.RedactVariables
+	if redact == nil {
+		redact = DefaultRedactVariables
+	}
+
+	c.OnRequest(operationName, redact(vars))
+}
+
+// onResponse calls the OnResponse hook with the JSON form of respData, its RedactResponseFields masked
+func (c *Client) onResponse(operationName string, respData interface{}, err error) {
+	if c.OnResponse == nil {
+		return
+	}
+
+	var data map[string]interface{}
+	if err == nil {
+		data = redactResponse(respData, append(c.RedactResponseFields["*"], c.RedactResponseFields[operationName]...))
+	}
+
+	c.OnResponse(operationName, data, err)
+}
+
+// redactResponse returns the JSON form of respData with the fields at paths masked, respData is left untouched
+func redactResponse(respData interface{}, paths []string) map[string]interface{} {
+	content, err := json.Marshal(respData)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	var redacted map[string]interface{}
+	if err := json.Unmarshal(content, &redacted); err != nil {
+		return map[string]interface{}{}
+	}
+
+	for _, path := range paths {
+		redactPath(redacted, strings.Split(path, "."))
+	}
+
+	return redacted
+}
+
+// redactPath masks the non-null fields at path in value, traversing the lists
+func redactPath(value interface{}, path []string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		field, ok := value[path[0]]
+		if !ok || field == nil {
+			return
+		}
+		if len(path) == 1 {
+			value[path[0]] = RedactedValue
+
+			return
+		}
+		redactPath(field, path[1:])
+	case []interface{}:
+		for _, v := range value {
+			redactPath(v, path)
+		}
+	}
+}
+
+// minRedactedSecretLength is the length under which a credential is too short to be masked without garbling messages
+const minRedactedSecretLength = 8
+
+// authorizationSecret returns the credentials of the Authorization header, without their scheme
+func authorizationSecret(header http.Header) string {
+	value := header.Get("Authorization")
+	if _, credentials, ok := strings.Cut(value, " "); ok {
+		value = credentials
+	}
+
+	return strings.TrimSpace(value)
+}
+
+// redactSecret masks the occurrences of secret in s
+func redactSecret(s, secret string) string {
+	if len(secret) < minRedactedSecretLength {
+		return s
+	}
+
+	return strings.ReplaceAll(s, secret, RedactedValue)
+}
+
+// redactedError masks a secret in the message of err, errors.Is and errors.As still match err
+type redactedError struct {
+	err    error
+	secret string
+}
+
+func redactError(err error, secret string) error {
+	if len(secret) < minRedactedSecretLength {
+		return err
+	}
+
+	return &redactedError{err: err, secret: secret}
+}
+
+func (e *redactedError) Error() string {
+	return redactSecret(e.err.Error(), e.secret)
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactResponseBody masks secret in the body of an error response, which ends up in the error messages
+func redactResponseBody(resp *http.Response, secret string) error {
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	resp.Body = ioutil.NopCloser(strings.NewReader(redactSecret(string(body), secret)))
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// RedirectPolicy is how the http clients built by NewHTTPClient follow the redirects of the endpoint
+type RedirectPolicy int
+
+const (
+	// RedirectNone fails the requests redirected with ErrRedirect, instead of following the redirect
+	// without the Authorization header http.Client drops for other hosts
+	RedirectNone RedirectPolicy = iota
+	// RedirectSameHost follows the redirects to the host of the request, keeping the Authorization header,
+	// and fails the others with ErrRedirect. Redirects from https to http are refused.
+	RedirectSameHost
+	// RedirectKeepAuthorization follows every redirect, sending the Authorization header to the new location.
+	// Only use it for endpoints redirecting to hosts trusted with the credentials.
+	RedirectKeepAuthorization
+)
+
+// maxRedirects is the number of redirects followed by a request before failing with ErrRedirect
+const maxRedirects = 10
+
+// ErrRedirect is returned when a request is redirected and the RedirectPolicy does not follow the redirect
+var ErrRedirect = xerrors.New("redirect not followed")
+
+// NewHTTPClient returns an http client following the redirects according to policy, timeout bounds the requests
+// when not 0. NewClient builds one when ClientOptions.HTTPClient is not set.
+func NewHTTPClient(timeout time.Duration, policy RedirectPolicy) *http.Client {
+	return &http.Client{
+		Timeout:       timeout,
+		CheckRedirect: policy.checkRedirect,
+	}
+}
+
+// checkRedirect is the http.Client.CheckRedirect of the policy
+func (p RedirectPolicy) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return xerrors.Errorf("%w: stopped after %d redirects", ErrRedirect, maxRedirects)
+	}
+
+	first := via[0]
+	switch p {
+	case RedirectSameHost:
+		if req.URL.Host != first.URL.Host || (first.URL.Scheme == "https" && req.URL.Scheme != "https") {
+			return xerrors.Errorf("%w: %s redirects to %s", ErrRedirect, first.URL.Redacted(), req.URL.Redacted())
+		}
+	case RedirectKeepAuthorization:
+	default:
+		return xerrors.Errorf("%w: %s redirects to %s", ErrRedirect, first.URL.Redacted(), req.URL.Redacted())
+	}
+
+	if authorization := first.Header.Get("Authorization"); authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	return nil
+}
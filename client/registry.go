@@ -0,0 +1,14 @@
+package client
+
+import "reflect"
+
+// OperationTypes describe an operation of a generated client, for tooling enumerating and introspecting the
+// operations of the client
+type OperationTypes struct {
+	Name     string
+	Document string
+	// Variables is the type of the <Operation>Variables struct, nil for the operations without variables
+	Variables reflect.Type
+	// Response is the type of the response struct the data of the operation is decoded into
+	Response reflect.Type
+}
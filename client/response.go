@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/perchcredit/gqlgenc/graphqljson"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"golang.org/x/xerrors"
+)
+
+// Response is a complete GraphQL response, carrying the decoded data
+// alongside the graphql errors and extensions returned by the server
+type Response[T any] struct {
+	// nil when the server did not return any data
+	Data       *T
+	Errors     gqlerror.List
+	Extensions map[string]interface{}
+}
+
+// HasErrors returns true when the server returned at least one graphql error
+func (r *Response[T]) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// MarshalResponse encodes data, a decoded response struct of the generated client, into the {"data": ...} response
+// body the server would have returned, to cache or replay it in tests. The keys follow the fields of the struct.
+func MarshalResponse(data interface{}) ([]byte, error) {
+	encoded, err := graphqljson.MarshalData(data)
+	if err != nil {
+		return nil, xerrors.Errorf("encode response: %w", err)
+	}
+
+	return json.Marshal(map[string]json.RawMessage{"data": encoded})
+}
+
+// PostTyped sends a http POST request to the graphql endpoint with the given query then unpacks
+// the data, errors and extensions of the response into a Response.
+// GraphQL errors are reported through Response.Errors, the returned error is only set
+// when the request failed or the http status code is not OK. Like Post, it is served from
+// Cache and coalesced when they are enabled, and calls OnResponse.
+func PostTyped[T any](ctx context.Context, c *Client, operationName, query string, vars map[string]interface{}, httpRequestOptions ...HTTPRequestOption) (*Response[T], error) {
+	body, statusCode, header, err := c.cachedDo(ctx, operationName, query, vars, httpRequestOptions)
+	if err != nil {
+		c.onResponse(operationName, nil, err)
+
+		return nil, err
+	}
+
+	resp, err := parseTypedResponse[T](body, statusCode, header, c.decodeOptions()...)
+	if errResponse, ok := err.(*ErrorResponse); ok {
+		errResponse.Precedence = c.ErrorPrecedence
+	}
+
+	// OnResponse is given the graphql errors as Post reports them
+	hookErr := err
+	if hookErr == nil && resp.HasErrors() {
+		hookErr = &ErrorResponse{GqlErrors: &resp.Errors, Precedence: c.ErrorPrecedence}
+	}
+	var data interface{}
+	if resp != nil {
+		data = resp.Data
+	}
+	c.onResponse(operationName, data, hookErr)
+
+	return resp, err
+}
+
+// typedResponse is the raw shape of a GraphQL response before data is decoded.
+type typedResponse struct {
+	Data       json.RawMessage        `json:"data"`
+	Errors     gqlerror.List          `json:"errors"`
+	Extensions map[string]interface{} `json:"extensions"`
+}
+
+func parseTypedResponse[T any](body []byte, httpCode int, header http.Header, decodeOptions ...graphqljson.Option) (*Response[T], error) {
+	var errResponse *ErrorResponse
+	isKOCode := httpCode < 200 || 299 < httpCode
+	if isKOCode {
+		errResponse = &ErrorResponse{
+			NetworkError: &HTTPError{
+				Code:    httpCode,
+				Message: errorStatusMessage(body, httpCode, header),
+			},
+		}
+	}
+
+	raw := typedResponse{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		// if is KO code there is already the http error, this error should not be returned
+		if isKOCode {
+			return nil, errResponse
+		}
+
+		return nil, xerrors.Errorf("failed to decode data %s: %w", string(body), err)
+	}
+
+	resp := &Response[T]{
+		Errors:     raw.Errors,
+		Extensions: raw.Extensions,
+	}
+
+	if len(raw.Data) > 0 && string(raw.Data) != "null" {
+		var data T
+		if err := graphqljson.UnmarshalData(raw.Data, &data, decodeOptions...); err != nil {
+			return nil, xerrors.Errorf("failed to decode data into response %s: %w", string(body), err)
+		}
+
+		resp.Data = &data
+	}
+
+	if isKOCode {
+		if resp.HasErrors() {
+			errResponse.GqlErrors = &resp.Errors
+		}
+
+		return resp, errResponse
+	}
+
+	return resp, nil
+}
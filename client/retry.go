@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// DefaultRetryBackoff is the delay before the first retry when Client.RetryBackoff is not set
+	DefaultRetryBackoff = 100 * time.Millisecond
+	// maxRetryBackoff caps the delay between two attempts, Retry-After headers included
+	maxRetryBackoff = 10 * time.Second
+
+	// DefaultRetryBudgetRatio and DefaultRetryBudgetBurst configure the budget of the clients retrying
+	// requests without a RetryBudget, at most one retry every ten calls after a burst of ten retries
+	DefaultRetryBudgetRatio = 0.1
+	DefaultRetryBudgetBurst = 10
+)
+
+// RetryBudget is a token bucket capping the retries of the clients sharing it to a ratio of their calls,
+// so that retrying the failing requests does not multiply the load of an endpoint in an outage
+type RetryBudget struct {
+	ratio float64
+	max   float64
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+// NewRetryBudget returns a budget earning ratio retries per call, 0.1 for at most one retry every ten calls,
+// and holding at most burst retries, all available at first
+func NewRetryBudget(ratio float64, burst int) *RetryBudget {
+	return &RetryBudget{
+		ratio:  ratio,
+		max:    float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// deposit earns the retries of a call
+func (b *RetryBudget) deposit() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.ratio
+	if b.tokens > b.max {
+		b.tokens = b.max
+	}
+}
+
+// withdraw spends a retry, it returns false when the budget is exhausted
+func (b *RetryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+
+	return true
+}
+
+type maxRetriesKey struct{}
+
+// WithMaxRetries returns a context overriding Client.MaxRetries for the calls made with it, 0 disabling their retries.
+// The retries are still capped by the RetryBudget of the client, when set.
+func WithMaxRetries(ctx context.Context, maxRetries int) context.Context {
+	return context.WithValue(ctx, maxRetriesKey{}, maxRetries)
+}
+
+func (c *Client) maxRetries(ctx context.Context) int {
+	if override, ok := ctx.Value(maxRetriesKey{}).(int); ok && override >= 0 {
+		return override
+	}
+
+	return c.MaxRetries
+}
+
+// sendError is the failure of sending a request, such as a connection reset
+type sendError struct {
+	err error
+}
+
+func (e *sendError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sendError) Unwrap() error {
+	return e.err
+}
+
+// retriable reports whether a failed attempt is worth retrying: the request failed to be sent or the server was
+// unavailable, and sending the operation twice is safe, mutations only being retried with an idempotency key
+func (c *Client) retriable(ctx context.Context, operationName, query string, statusCode int, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		var failed *sendError
+		if !xerrors.As(err, &failed) || xerrors.Is(err, ErrCircuitOpen) || xerrors.Is(err, ErrRedirect) {
+			return false
+		}
+	} else {
+		switch statusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		default:
+			return false
+		}
+	}
+
+	return operationType(operationName, query) != ast.Mutation || (c.Transport == nil && c.IdempotencyKeyHeader != "")
+}
+
+// retryDelay returns the delay before the retry following the attempt, the Retry-After of the response
+// when set, otherwise an exponential backoff with jitter
+func (c *Client) retryDelay(attempt int, header http.Header) time.Duration {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds >= 0 {
+		delay := time.Duration(seconds) * time.Second
+		if delay > maxRetryBackoff {
+			delay = maxRetryBackoff
+		}
+
+		return delay
+	}
+
+	delay := c.RetryBackoff
+	if delay <= 0 {
+		delay = DefaultRetryBackoff
+	}
+	for i := 0; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,21 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+)
+
+// SchemaHash returns the hex encoded sha256 of the formatted schema. The SchemaHash constant generated
+// with the schemaHash option is the hash of the schema of the client, a server computing the hash of its own
+// schema with this function lets the client check both match.
+func SchemaHash(schema *ast.Schema) string {
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf).FormatSchema(schema)
+	hash := sha256.Sum256(buf.Bytes())
+
+	return hex.EncodeToString(hash[:])
+}
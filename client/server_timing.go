@@ -0,0 +1,81 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerTiming is a metric of the Server-Timing response header
+type ServerTiming struct {
+	Name string
+	// Duration is zero when the metric has no dur parameter
+	Duration    time.Duration
+	Description string
+}
+
+// ParseServerTiming returns the metrics of the Server-Timing headers, skipping the malformed ones
+func ParseServerTiming(header http.Header) []ServerTiming {
+	var timings []ServerTiming
+	for _, value := range header.Values("Server-Timing") {
+		for _, metric := range splitOutsideQuotes(value, ',') {
+			params := splitOutsideQuotes(metric, ';')
+			timing := ServerTiming{Name: strings.TrimSpace(params[0])}
+			if timing.Name == "" {
+				continue
+			}
+
+			for _, param := range params[1:] {
+				key, value, _ := strings.Cut(param, "=")
+				value = strings.TrimSpace(value)
+				switch strings.ToLower(strings.TrimSpace(key)) {
+				case "dur":
+					if ms, err := strconv.ParseFloat(value, 64); err == nil {
+						timing.Duration = time.Duration(ms * float64(time.Millisecond))
+					}
+				case "desc":
+					if unquoted, err := strconv.Unquote(value); err == nil {
+						value = unquoted
+					}
+					timing.Description = value
+				}
+			}
+			timings = append(timings, timing)
+		}
+	}
+
+	return timings
+}
+
+// splitOutsideQuotes splits s around the separators which are not inside a quoted string
+func splitOutsideQuotes(s string, separator rune) []string {
+	var parts []string
+	quoted, escaped, start := false, false, 0
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quoted:
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == separator && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+// onServerTiming reports the Server-Timing metrics of a response to OnServerTiming
+func (c *Client) onServerTiming(operationName string, header http.Header) {
+	if c.OnServerTiming == nil {
+		return
+	}
+
+	if timings := ParseServerTiming(header); len(timings) > 0 {
+		c.OnServerTiming(operationName, timings)
+	}
+}
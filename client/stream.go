@@ -0,0 +1,210 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"golang.org/x/xerrors"
+)
+
+// PostStream sends a http POST request to the graphql endpoint with the given query then decodes
+// the list returned under the top-level field of data one element at a time, calling onElement
+// with the raw JSON of each element as soon as it is read from the response body.
+// This keeps memory usage flat for queries returning large lists.
+// Like Post it retries, applies the RequestTimeout, which also bounds the reading of the body, and sends the idempotency key.
+func (c *Client) PostStream(ctx context.Context, operationName, query, field string, vars map[string]interface{}, onElement func(element json.RawMessage) error, httpRequestOptions ...HTTPRequestOption) error {
+	// A Transport returns the whole body, decode it element by element all the same
+	if c.Transport != nil {
+		body, statusCode, header, err := c.do(ctx, operationName, query, vars, httpRequestOptions)
+		if err != nil {
+			return err
+		}
+		if statusCode < 200 || 299 < statusCode {
+			return c.parse(body, statusCode, header, &json.RawMessage{})
+		}
+
+		return c.decodeStream(bytes.NewReader(body), field, onElement)
+	}
+
+	httpRequestOptions, err := c.idempotencyKey(operationName, query, httpRequestOptions)
+	if err != nil {
+		return err
+	}
+
+	// The attempts are retried until the response headers are received, the successful one is kept open
+	// with its RequestTimeout while its body is streamed
+	var resp *http.Response
+	var cancel context.CancelFunc
+	body, statusCode, header, err := c.retry(ctx, operationName, query, vars, func(ctx context.Context) ([]byte, int, http.Header, error) {
+		attemptCtx, attemptCancel := c.withRequestTimeout(ctx)
+		attemptResp, err := c.send(attemptCtx, operationName, query, vars, httpRequestOptions)
+		if err != nil {
+			attemptCancel()
+
+			return nil, 0, nil, err
+		}
+		if 200 <= attemptResp.StatusCode && attemptResp.StatusCode <= 299 {
+			resp, cancel = attemptResp, attemptCancel
+
+			return nil, attemptResp.StatusCode, attemptResp.Header, nil
+		}
+
+		// Error responses are small, fall back to the standard parsing
+		defer attemptCancel()
+		defer attemptResp.Body.Close()
+		body, err := ioutil.ReadAll(attemptResp.Body)
+		if err != nil {
+			return nil, 0, nil, xerrors.Errorf("failed to read response body: %w", err)
+		}
+
+		return body, attemptResp.StatusCode, attemptResp.Header, nil
+	})
+	if err != nil {
+		return err
+	}
+	if resp == nil {
+		return c.parse(body, statusCode, header, &json.RawMessage{})
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	return c.decodeStream(resp.Body, field, onElement)
+}
+
+// decodeStream is decodeStream with the ErrorPrecedence of the client
+func (c *Client) decodeStream(r io.Reader, field string, onElement func(element json.RawMessage) error) error {
+	err := decodeStream(r, field, onElement)
+	if errResponse, ok := err.(*ErrorResponse); ok {
+		errResponse.Precedence = c.ErrorPrecedence
+	}
+
+	return err
+}
+
+func decodeStream(r io.Reader, field string, onElement func(element json.RawMessage) error) error {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+
+	if err := expectDelim(d, '{'); err != nil {
+		return err
+	}
+
+	var gqlErrors gqlerror.List
+	for d.More() {
+		key, err := d.Token()
+		if err != nil {
+			return xerrors.Errorf("failed to decode response: %w", err)
+		}
+
+		switch key {
+		case "data":
+			if err := decodeStreamData(d, field, onElement); err != nil {
+				return err
+			}
+		case "errors":
+			if err := d.Decode(&gqlErrors); err != nil {
+				return xerrors.Errorf("faild to parse graphql errors: %w", err)
+			}
+		default:
+			if err := skipValue(d); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(gqlErrors) > 0 {
+		return &ErrorResponse{GqlErrors: &gqlErrors}
+	}
+
+	return nil
+}
+
+// decodeStreamData walks the data object and streams the elements of field
+func decodeStreamData(d *json.Decoder, field string, onElement func(element json.RawMessage) error) error {
+	tok, err := d.Token()
+	if err != nil {
+		return xerrors.Errorf("failed to decode data: %w", err)
+	}
+
+	// data is null when the server returned errors only
+	if tok == nil {
+		return nil
+	}
+
+	if tok != json.Delim('{') {
+		return xerrors.Errorf("unexpected token %v in data", tok)
+	}
+
+	for d.More() {
+		key, err := d.Token()
+		if err != nil {
+			return xerrors.Errorf("failed to decode data: %w", err)
+		}
+
+		if key != field {
+			if err := skipValue(d); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		tok, err := d.Token()
+		if err != nil {
+			return xerrors.Errorf("failed to decode %s: %w", field, err)
+		}
+
+		// nullable list field
+		if tok == nil {
+			continue
+		}
+
+		if tok != json.Delim('[') {
+			return xerrors.Errorf("field %s is not a list", field)
+		}
+
+		for d.More() {
+			var element json.RawMessage
+			if err := d.Decode(&element); err != nil {
+				return xerrors.Errorf("failed to decode %s element: %w", field, err)
+			}
+
+			if err := onElement(element); err != nil {
+				return err
+			}
+		}
+
+		if err := expectDelim(d, ']'); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(d, '}')
+}
+
+func expectDelim(d *json.Decoder, delim json.Delim) error {
+	tok, err := d.Token()
+	if err != nil {
+		return xerrors.Errorf("failed to decode response: %w", err)
+	}
+
+	if tok != delim {
+		return xerrors.Errorf("unexpected token %v, expected %v", tok, delim)
+	}
+
+	return nil
+}
+
+func skipValue(d *json.Decoder) error {
+	var skipped json.RawMessage
+	if err := d.Decode(&skipped); err != nil {
+		return xerrors.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
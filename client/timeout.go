@@ -0,0 +1,27 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+type requestTimeoutKey struct{}
+
+// WithRequestTimeout returns a context overriding Client.RequestTimeout for the calls made with it, 0 removing
+// their timeout. Unlike context.WithTimeout, each attempt of a retried call gets the whole timeout.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, timeout)
+}
+
+// withRequestTimeout returns the context of an attempt, bounded by the request timeout of ctx or RequestTimeout
+func (c *Client) withRequestTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := c.RequestTimeout
+	if override, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok && override >= 0 {
+		timeout = override
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
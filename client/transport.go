@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/xerrors"
+)
+
+// Transport sends operations over another medium than HTTP, a message bus for instance
+type Transport interface {
+	Do(ctx context.Context, req *Request) (*TransportResponse, error)
+}
+
+// TransportFunc adapts a function to a Transport
+type TransportFunc func(ctx context.Context, req *Request) (*TransportResponse, error)
+
+// Do calls f(ctx, req)
+func (f TransportFunc) Do(ctx context.Context, req *Request) (*TransportResponse, error) {
+	return f(ctx, req)
+}
+
+// TransportResponse is the response of a Transport
+type TransportResponse struct {
+	// Body is the GraphQL response, a JSON object holding data, errors and extensions
+	Body []byte
+	// StatusCode is the HTTP status code equivalent of the response, http.StatusOK when 0
+	StatusCode int
+	// Header holds the metadata of the response, may be nil
+	Header http.Header
+}
+
+// ErrTransportUploads is returned when sending uploads through a Transport, only the HTTP transport sends them
+var ErrTransportUploads = xerrors.New("uploads are only sent over HTTP")
+
+// doTransport sends the operation through Transport instead of HTTP, the http request options are not applied
+func (c *Client) doTransport(ctx context.Context, operationName, query string, vars map[string]interface{}) ([]byte, int, http.Header, error) {
+	if err := c.checkAllowed(operationName); err != nil {
+		return nil, 0, nil, err
+	}
+
+	if err := validateVariables(vars); err != nil {
+		return nil, 0, nil, xerrors.Errorf("%s: %w", operationName, err)
+	}
+
+	if len(collectUploads(vars)) > 0 {
+		return nil, 0, nil, xerrors.Errorf("%s: %w", operationName, ErrTransportUploads)
+	}
+
+	c.onRequest(operationName, vars)
+
+	vars = c.formatVariables(vars)
+
+	release, err := c.acquireRequestSlot(ctx, operationName)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer release()
+
+	resp, err := c.Transport.Do(ctx, &Request{
+		Query:         query,
+		Variables:     vars,
+		OperationName: operationName,
+	})
+	if err != nil {
+		return nil, 0, nil, xerrors.Errorf("%s: request failed: %w", operationName, &sendError{err: err})
+	}
+
+	statusCode := resp.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	return resp.Body, statusCode, resp.Header, nil
+}
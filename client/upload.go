@@ -0,0 +1,230 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Upload is a file variable, requests holding uploads are sent following the GraphQL multipart
+// request specification, map the Upload scalar to this type to use it
+type Upload struct {
+	File     io.Reader
+	Filename string
+	// ContentType is the media type of File, sniffed from its first bytes when empty
+	ContentType string
+	// Size is the size of File in bytes, used to report the upload progress
+	Size int64
+}
+
+// MarshalJSON encodes the upload as null, the file is sent in its own part
+func (u Upload) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+var uploadType = reflect.TypeOf(Upload{})
+
+// fileUpload is an upload and the path of its variable in the operations part
+type fileUpload struct {
+	path   string
+	upload Upload
+}
+
+// collectUploads returns the uploads of vars, including the ones nested in maps, slices and input structs
+func collectUploads(vars map[string]interface{}) []fileUpload {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var uploads []fileUpload
+	for _, key := range keys {
+		uploads = appendUploads(uploads, "variables."+key, reflect.ValueOf(vars[key]))
+	}
+
+	return uploads
+}
+
+func appendUploads(uploads []fileUpload, path string, v reflect.Value) []fileUpload {
+	if !v.IsValid() {
+		return uploads
+	}
+
+	if v.Type() == uploadType {
+		return append(uploads, fileUpload{path: path, upload: v.Interface().(Upload)})
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return appendUploads(uploads, path, v.Elem())
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return uploads
+		}
+
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			uploads = appendUploads(uploads, path+"."+key.String(), v.MapIndex(key))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			uploads = appendUploads(uploads, path+"."+strconv.Itoa(i), v.Index(i))
+		}
+	case reflect.Struct:
+		if v.Type().Implements(marshalerType) || reflect.PtrTo(v.Type()).Implements(marshalerType) {
+			return uploads
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" || field.PkgPath != "" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			uploads = appendUploads(uploads, path+"."+name, v.Field(i))
+		}
+	}
+
+	return uploads
+}
+
+// uploadBody streams a multipart request body, the parts are written once the body is first read
+type uploadBody struct {
+	once   sync.Once
+	reader *io.PipeReader
+	writer *io.PipeWriter
+	write  func(w io.Writer) error
+}
+
+func (b *uploadBody) Read(p []byte) (int, error) {
+	b.once.Do(func() {
+		go func() {
+			b.writer.CloseWithError(b.write(b.writer))
+		}()
+	})
+
+	return b.reader.Read(p)
+}
+
+func (b *uploadBody) Close() error {
+	return b.reader.Close()
+}
+
+// multipartBody returns the multipart request body sending operations and the uploads, and its content type
+func (c *Client) multipartBody(operations []byte, uploads []fileUpload) (io.ReadCloser, string, error) {
+	fileMap := make(map[string][]string, len(uploads))
+	total := int64(0)
+	for i, upload := range uploads {
+		fileMap[strconv.Itoa(i)] = []string{upload.path}
+		if total >= 0 && upload.upload.Size > 0 {
+			total += upload.upload.Size
+		} else {
+			total = -1
+		}
+	}
+
+	mapPart, err := json.Marshal(fileMap)
+	if err != nil {
+		return nil, "", err
+	}
+
+	reader, writer := io.Pipe()
+	mw := multipart.NewWriter(writer)
+	body := &uploadBody{
+		reader: reader,
+		writer: writer,
+		write: func(w io.Writer) error {
+			if err := mw.WriteField("operations", string(operations)); err != nil {
+				return err
+			}
+			if err := mw.WriteField("map", string(mapPart)); err != nil {
+				return err
+			}
+
+			sent := int64(0)
+			for i, upload := range uploads {
+				file, contentType, err := upload.upload.content()
+				if err != nil {
+					return err
+				}
+
+				header := make(textproto.MIMEHeader)
+				header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, strconv.Itoa(i), quoteEscaper.Replace(upload.upload.Filename)))
+				header.Set("Content-Type", contentType)
+				part, err := mw.CreatePart(header)
+				if err != nil {
+					return err
+				}
+
+				if c.OnUploadProgress != nil {
+					file = &progressReader{reader: file, sent: &sent, total: total, onProgress: c.OnUploadProgress}
+				}
+				if _, err := io.Copy(part, file); err != nil {
+					return err
+				}
+			}
+
+			return mw.Close()
+		},
+	}
+
+	return body, mw.FormDataContentType(), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// content returns the file of the upload and its content type, sniffing it when unset
+func (u Upload) content() (io.Reader, string, error) {
+	if u.File == nil {
+		return strings.NewReader(""), "application/octet-stream", nil
+	}
+
+	if u.ContentType != "" {
+		return u.File, u.ContentType, nil
+	}
+
+	// http.DetectContentType considers at most the first 512 bytes
+	sniffed := make([]byte, 512)
+	n, err := io.ReadFull(u.File, sniffed)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, "", err
+	}
+	sniffed = sniffed[:n]
+
+	return io.MultiReader(bytes.NewReader(sniffed), u.File), http.DetectContentType(sniffed), nil
+}
+
+// progressReader reports the bytes read from the files of a request
+type progressReader struct {
+	reader     io.Reader
+	sent       *int64
+	total      int64
+	onProgress func(bytesSent, total int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		*r.sent += int64(n)
+		r.onProgress(*r.sent, r.total)
+	}
+
+	return n, err
+}
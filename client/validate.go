@@ -0,0 +1,101 @@
+package client
+
+import (
+	"reflect"
+
+	"golang.org/x/xerrors"
+)
+
+// ErrOneOf is returned by the generated Validate method of a @oneOf input not having exactly one field set
+var ErrOneOf = xerrors.New("@oneOf input must have exactly one field set")
+
+// ErrConstraint is returned by the generated Validate method of an input whose field breaks its constraint directive
+var ErrConstraint = xerrors.New("input constraint violated")
+
+// ErrMissingVariables is returned by the generated Validate method of operation variables leaving required ones nil
+var ErrMissingVariables = xerrors.New("required variables are not set")
+
+// Validator is implemented by the input types validated before sending a request, such as @oneOf inputs
+type Validator interface {
+	Validate() error
+}
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// validateVariables validates the variables implementing Validator, including the ones nested
+// in maps, slices and input structs
+func validateVariables(vars map[string]interface{}) error {
+	for name, value := range vars {
+		if err := validateValue(reflect.ValueOf(value)); err != nil {
+			return xerrors.Errorf("variable %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateValue(v reflect.Value) error {
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+
+		return validateValue(v.Elem())
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validateValue(iter.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		if validator, ok := asValidator(v); ok {
+			if err := validator.Validate(); err != nil {
+				return err
+			}
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				// Skip unexported field.
+				continue
+			}
+			if err := validateValue(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// asValidator returns the Validator of a struct, whether Validate has a value or a pointer receiver
+func asValidator(v reflect.Value) (Validator, bool) {
+	if v.Type().Implements(validatorType) {
+		return v.Interface().(Validator), true
+	}
+
+	if !reflect.PointerTo(v.Type()).Implements(validatorType) {
+		return nil, false
+	}
+
+	if v.CanAddr() {
+		return v.Addr().Interface().(Validator), true
+	}
+
+	addressable := reflect.New(v.Type())
+	addressable.Elem().Set(v)
+
+	return addressable.Interface().(Validator), true
+}
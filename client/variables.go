@@ -0,0 +1,234 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	// optionalValueType is implemented by every Optional
+	optionalValueType = reflect.TypeOf((*interface{ optional() })(nil)).Elem()
+)
+
+// NullValue is the type of Null
+type NullValue struct{}
+
+// Null is a variable value sent as an explicit null where a nil value would be dropped or replaced, such as
+// in a field tagged omitempty or for a variable having a default value
+var Null = NullValue{}
+
+// MarshalJSON marshals the value as null
+func (NullValue) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// formatVariables returns vars with the time.Time values encoded in TimeLayout when set, and without the unset
+// Optionals as omitzero is ignored by encoding/json before Go 1.24
+func (c *Client) formatVariables(vars map[string]interface{}) map[string]interface{} {
+	if c.TimeLayout != "" {
+		return formatTimeVariables(vars, c.TimeLayout)
+	}
+
+	if hasOptionals(reflect.ValueOf(vars)) {
+		return formatTimeVariables(vars, "")
+	}
+
+	return vars
+}
+
+// formatTimeVariables returns a copy of vars where every time.Time value, including the ones
+// nested in maps, slices and input structs, is replaced by its UTC representation in the given layout,
+// the time.Time values are left as they are when layout is empty. The input structs are replaced by maps
+// without their fields omitted by omitempty and omitzero.
+func formatTimeVariables(vars map[string]interface{}, layout string) map[string]interface{} {
+	if vars == nil {
+		return nil
+	}
+
+	formatted := make(map[string]interface{}, len(vars))
+	for key, value := range vars {
+		formatted[key] = formatTimeValue(reflect.ValueOf(value), layout)
+	}
+
+	return formatted
+}
+
+func formatTimeValue(v reflect.Value, layout string) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Type() == timeType && layout != "" {
+		return v.Interface().(time.Time).UTC().Format(layout)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return v.Interface()
+		}
+
+		return formatTimeValue(v.Elem(), layout)
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+
+		formatted := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			formatted[iter.Key().String()] = formatTimeValue(iter.Value(), layout)
+		}
+
+		return formatted
+	case reflect.Slice, reflect.Array:
+		// nil slices are kept as is to marshal as null, byte slices marshal as base64 strings
+		if (v.Kind() == reflect.Slice && v.IsNil()) || v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+
+		formatted := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			formatted[i] = formatTimeValue(v.Index(i), layout)
+		}
+
+		return formatted
+	case reflect.Struct:
+		// types with their own json encoding are left untouched
+		if v.Type().Implements(marshalerType) || reflect.PtrTo(v.Type()).Implements(marshalerType) {
+			return v.Interface()
+		}
+
+		formatted := make(map[string]interface{}, v.NumField())
+		formatStructFields(v, layout, formatted)
+
+		return formatted
+	}
+
+	return v.Interface()
+}
+
+// formatStructFields fills formatted with the fields of struct v
+// following the naming rules of encoding/json
+func formatStructFields(v reflect.Value, layout string, formatted map[string]interface{}) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx != -1 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+
+		fieldValue := v.Field(i)
+		if field.Anonymous && name == "" {
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					continue
+				}
+				fieldValue = fieldValue.Elem()
+			}
+
+			if fieldValue.Kind() == reflect.Struct {
+				formatStructFields(fieldValue, layout, formatted)
+
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			// Skip unexported field.
+			continue
+		}
+
+		if strings.Contains(opts, "omitempty") && isEmptyValue(fieldValue) {
+			continue
+		}
+
+		if strings.Contains(opts, "omitzero") && isZeroValue(fieldValue) {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		formatted[name] = formatTimeValue(fieldValue, layout)
+	}
+}
+
+// hasOptionals reports whether v holds an Optional, in a field omitted when unset by omitzero for instance
+func hasOptionals(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+
+	if v.Type().Implements(optionalValueType) {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return !v.IsNil() && hasOptionals(v.Elem())
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if hasOptionals(iter.Value()) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return false
+		}
+		for i := 0; i < v.Len(); i++ {
+			if hasOptionals(v.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" && hasOptionals(v.Field(i)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isEmptyValue reports whether v is empty according to the omitempty rules of encoding/json
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+// isZeroValue reports whether v is zero according to the omitzero rules of encoding/json
+func isZeroValue(v reflect.Value) bool {
+	if z, ok := v.Interface().(interface{ IsZero() bool }); ok {
+		return z.IsZero()
+	}
+
+	return v.IsZero()
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// WatchEvent is a response of a watched operation, or the error of a failed poll
+type WatchEvent[T any] struct {
+	Response T
+	Err      error
+}
+
+// ErrWatchInterval is sent by Watch when the interval is not positive
+var ErrWatchInterval = xerrors.New("watch interval must be positive")
+
+// Watch calls fetch right away then every interval, and sends on the returned channel the errors
+// and the responses whose data changed since the previous response sent. The channel is closed once ctx is done.
+// When interval is not positive, the channel only holds an ErrWatchInterval event and is closed.
+func Watch[T any](ctx context.Context, interval time.Duration, fetch func(ctx context.Context) (T, json.RawMessage, error)) <-chan WatchEvent[T] {
+	if interval <= 0 {
+		events := make(chan WatchEvent[T], 1)
+		events <- WatchEvent[T]{Err: xerrors.Errorf("%s: %w", interval, ErrWatchInterval)}
+		close(events)
+
+		return events
+	}
+
+	events := make(chan WatchEvent[T])
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last json.RawMessage
+		for {
+			response, data, err := fetch(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err != nil || last == nil || !bytes.Equal(data, last) {
+				if err == nil {
+					last = data
+				}
+
+				select {
+				case events <- WatchEvent[T]{Response: response, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
@@ -0,0 +1,109 @@
+package clientgen
+
+import (
+	"go/types"
+	"strings"
+)
+
+// Accessor is a getter walking a chain of response fields holding at least one nullable field
+type Accessor struct {
+	Name string
+	// Path is the field chain from the response struct
+	Path string
+	// NilChecks are the nullable fields of the chain, outermost first
+	NilChecks []string
+	// Value is the dereferenced value of the last field
+	Value string
+	Type  types.Type
+}
+
+// accessors returns the getters of the fields reached through a nullable field in the response struct,
+// embedded fragments are walked as promoted fields and lists are not walked
+func accessors(typ types.Type, pkgPath string) []*Accessor {
+	w := &accessorWalker{pkgPath: pkgPath, names: map[string]bool{}}
+	w.walk(typ, nil, "", nil)
+
+	return w.accessors
+}
+
+type accessorWalker struct {
+	pkgPath   string
+	names     map[string]bool
+	accessors []*Accessor
+}
+
+func (w *accessorWalker) walk(typ types.Type, path []string, name string, nilChecks []string) {
+	st := w.structType(typ)
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		fieldPath := append(append([]string{}, path...), field.Name())
+		fieldName := name
+		if !field.Anonymous() {
+			fieldName += field.Name()
+		}
+		expr := "t." + strings.Join(fieldPath, ".")
+
+		fieldType := field.Type()
+		fieldNilChecks := nilChecks
+		value := expr
+		if ptr, ok := fieldType.(*types.Pointer); ok {
+			fieldType = ptr.Elem()
+			fieldNilChecks = append(append([]string{}, nilChecks...), expr)
+			value = "*" + expr
+		}
+
+		if w.structType(fieldType) != nil {
+			w.walk(fieldType, fieldPath, fieldName, fieldNilChecks)
+
+			continue
+		}
+
+		// only fields behind a nullable field need a getter
+		if len(nilChecks) == 0 || hasAnonymousStruct(fieldType) {
+			continue
+		}
+
+		if w.names[fieldName] {
+			continue
+		}
+		w.names[fieldName] = true
+
+		w.accessors = append(w.accessors, &Accessor{
+			Name:      "Get" + fieldName,
+			Path:      strings.Join(fieldPath, "."),
+			NilChecks: fieldNilChecks,
+			Value:     value,
+			Type:      fieldType,
+		})
+	}
+}
+
+// structType returns the struct to walk, anonymous structs and fragments of the client package
+func (w *accessorWalker) structType(typ types.Type) *types.Struct {
+	switch typ := typ.(type) {
+	case *types.Struct:
+		return typ
+	case *types.Named:
+		if typ.Obj().Pkg() == nil || typ.Obj().Pkg().Path() != w.pkgPath {
+			return nil
+		}
+		if st, ok := typ.Underlying().(*types.Struct); ok {
+			return st
+		}
+	}
+
+	return nil
+}
+
+func hasAnonymousStruct(typ types.Type) bool {
+	switch typ := typ.(type) {
+	case *types.Struct:
+		return true
+	case *types.Pointer:
+		return hasAnonymousStruct(typ.Elem())
+	case *types.Slice:
+		return hasAnonymousStruct(typ.Elem())
+	}
+
+	return false
+}
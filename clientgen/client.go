@@ -13,6 +13,8 @@ type Plugin struct {
 	queryFilePaths []string
 	Client         config.PackageConfig
 	GenerateConfig *gqlgencConfig.GenerateConfig
+	// Operations are the operations of the generated client, set once MutateConfig ran
+	Operations []*Operation
 }
 
 func New(queryFilePaths []string, client config.PackageConfig, generateConfig *gqlgencConfig.GenerateConfig) *Plugin {
@@ -49,7 +51,7 @@ func (p *Plugin) MutateConfig(cfg *config.Config) error {
 
 	// 3. テンプレートと情報ソースを元にコード生成
 	// 3. Generate code from template and document source
-	sourceGenerator := NewSourceGenerator(cfg, p.Client)
+	sourceGenerator := NewSourceGenerator(cfg, p.Client, p.GenerateConfig)
 	source := NewSource(cfg.Schema, queryDocument, sourceGenerator, p.GenerateConfig)
 	query, err := source.Query()
 	if err != nil {
@@ -71,9 +73,15 @@ func (p *Plugin) MutateConfig(cfg *config.Config) error {
 		return xerrors.Errorf("generating operation response failed: %w", err)
 	}
 
-	if err := RenderTemplate(cfg, query, mutation, fragments, source.Operations(queryDocuments), operationResponses, p.Client); err != nil {
+	operations, err := source.Operations(queryDocuments)
+	if err != nil {
+		return xerrors.Errorf("generating operations failed: %w", err)
+	}
+
+	if err := RenderTemplate(cfg, query, mutation, fragments, operations, operationResponses, p.Client, p.GenerateConfig); err != nil {
 		return xerrors.Errorf("template failed: %w", err)
 	}
+	p.Operations = operations
 
 	return nil
 }
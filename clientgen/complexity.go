@@ -0,0 +1,95 @@
+package clientgen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/perchcredit/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const (
+	defaultFieldWeight = 1
+	defaultListSize    = 10
+)
+
+// complexity estimates the cost of the operation, the sum of the weights of its fields times the number of
+// elements expected from the lists holding them
+func complexity(operation *ast.OperationDefinition, cfg *config.ComplexityConfig) int {
+	return selectionSetComplexity(operation.SelectionSet, cfg)
+}
+
+func selectionSetComplexity(selectionSet ast.SelectionSet, cfg *config.ComplexityConfig) int {
+	total := 0
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			total += fieldComplexity(selection, cfg)
+		case *ast.FragmentSpread:
+			if selection.Definition != nil {
+				total += selectionSetComplexity(selection.Definition.SelectionSet, cfg)
+			}
+		case *ast.InlineFragment:
+			// the fragments of every possible type are counted, an upper bound of the cost
+			total += selectionSetComplexity(selection.SelectionSet, cfg)
+		}
+	}
+
+	return total
+}
+
+func fieldComplexity(field *ast.Field, cfg *config.ComplexityConfig) int {
+	// meta fields such as __typename are free
+	if strings.HasPrefix(field.Name, "__") {
+		return 0
+	}
+
+	var key string
+	if field.ObjectDefinition != nil {
+		key = field.ObjectDefinition.Name + "." + field.Name
+	}
+
+	weight := defaultFieldWeight
+	if cfg.FieldWeight != 0 {
+		weight = cfg.FieldWeight
+	}
+	if w, ok := cfg.Weights[key]; ok {
+		weight = w
+	}
+
+	if len(field.SelectionSet) == 0 {
+		return weight
+	}
+
+	return weight + listSize(field, key, cfg)*selectionSetComplexity(field.SelectionSet, cfg)
+}
+
+// listSize returns the number of elements expected from the field, 1 for the fields which are not lists
+// nor have a first or last argument
+func listSize(field *ast.Field, key string, cfg *config.ComplexityConfig) int {
+	if size, ok := cfg.ListSizes[key]; ok {
+		return size
+	}
+
+	for _, name := range []string{"first", "last"} {
+		if arg := field.Arguments.ForName(name); arg != nil && arg.Value.Kind == ast.IntValue {
+			if size, err := strconv.Atoi(arg.Value.Raw); err == nil {
+				return size
+			}
+		}
+	}
+
+	size := defaultListSize
+	if cfg.ListSize != 0 {
+		size = cfg.ListSize
+	}
+
+	n := 1
+	if field.Definition != nil {
+		for typ := field.Definition.Type; typ.Elem != nil; typ = typ.Elem {
+			n *= size
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,58 @@
+package clientgen
+
+import (
+	"testing"
+
+	"github.com/perchcredit/gqlgenc/config"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestComplexity(t *testing.T) {
+	t.Parallel()
+	schema := gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: `
+		type Query {
+			user(id: ID!): User
+			users(first: Int): [User!]!
+			search(text: String!): [[User!]!]!
+		}
+		type User {
+			id: ID!
+			name: String!
+			friends(first: Int): [User!]!
+		}
+	`})
+	query, errs := gqlparser.LoadQuery(schema, `
+		query GetUser {
+			user(id: "1") {
+				__typename
+				...UserFields
+				friends(first: 3) { id }
+			}
+		}
+		query ListUsers {
+			users {
+				... on User { id friends { name } }
+			}
+		}
+		query Search {
+			search(text: "a") { id }
+		}
+		fragment UserFields on User { id name }
+	`)
+	require.Nil(t, errs)
+
+	cfg := &config.ComplexityConfig{}
+	require.Equal(t, 1+2+(1+3*1), complexity(query.Operations.ForName("GetUser"), cfg))
+	require.Equal(t, 1+10*(1+(1+10*1)), complexity(query.Operations.ForName("ListUsers"), cfg))
+	require.Equal(t, 1+10*10*1, complexity(query.Operations.ForName("Search"), cfg))
+
+	cfg = &config.ComplexityConfig{
+		FieldWeight: 2,
+		ListSize:    5,
+		Weights:     map[string]int{"User.name": 0},
+		ListSizes:   map[string]int{"Query.users": 100},
+	}
+	require.Equal(t, 2+100*(2+(2+5*0)), complexity(query.Operations.ForName("ListUsers"), cfg))
+}
@@ -0,0 +1,105 @@
+package clientgen
+
+import (
+	"strings"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Condition is a variable of an @include or @skip directive, toggling the selection of its fields at runtime
+type Condition struct {
+	Variable string
+	// Directive is include or skip
+	Directive string
+	// Paths are the response paths of the selections the variable toggles
+	Paths []string
+}
+
+// operationConditions returns the variables of the @include and @skip directives of the operation, in the order
+// they are first used
+func operationConditions(operation *ast.OperationDefinition) []*Condition {
+	var conditions []*Condition
+	byKey := make(map[string]*Condition)
+	var walk func(selectionSet ast.SelectionSet, path []string, fragments map[string]bool)
+	walk = func(selectionSet ast.SelectionSet, path []string, fragments map[string]bool) {
+		for _, selection := range selectionSet {
+			var (
+				name       string
+				directives ast.DirectiveList
+				children   ast.SelectionSet
+				childPath  []string
+			)
+			switch selection := selection.(type) {
+			case *ast.Field:
+				name, directives, children = selection.Alias, selection.Directives, selection.SelectionSet
+				childPath = append(append([]string(nil), path...), selection.Alias)
+			case *ast.FragmentSpread:
+				name, directives = "..."+selection.Name, selection.Directives
+				childPath = path
+				// fragments spreading themselves are rejected by the validation, spreads of a same fragment
+				// in one branch are only walked once
+				if selection.Definition != nil && !fragments[selection.Name] {
+					children = selection.Definition.SelectionSet
+					fragments = copyFragments(fragments, selection.Name)
+				}
+			case *ast.InlineFragment:
+				name, directives, children = "... on "+selection.TypeCondition, selection.Directives, selection.SelectionSet
+				childPath = path
+			}
+
+			for _, directive := range directives {
+				if directive.Name != "include" && directive.Name != "skip" {
+					continue
+				}
+
+				arg := directive.Arguments.ForName("if")
+				if arg == nil || arg.Value.Kind != ast.Variable {
+					continue
+				}
+
+				key := directive.Name + "$" + arg.Value.Raw
+				condition, ok := byKey[key]
+				if !ok {
+					condition = &Condition{Variable: arg.Value.Raw, Directive: directive.Name}
+					byKey[key] = condition
+					conditions = append(conditions, condition)
+				}
+				condition.Paths = append(condition.Paths, selectionPath(path, name))
+			}
+
+			walk(children, childPath, fragments)
+		}
+	}
+	walk(operation.SelectionSet, nil, map[string]bool{})
+
+	return conditions
+}
+
+// selectionPath returns the path of the selection name under path, fragments are appended to their parent
+func selectionPath(path []string, name string) string {
+	parent := strings.Join(path, ".")
+	switch {
+	case parent == "":
+		return name
+	case strings.HasPrefix(name, "..."):
+		return parent + " " + name
+	}
+
+	return parent + "." + name
+}
+
+func copyFragments(fragments map[string]bool, name string) map[string]bool {
+	copied := make(map[string]bool, len(fragments)+1)
+	for fragment := range fragments {
+		copied[fragment] = true
+	}
+	copied[name] = true
+
+	return copied
+}
+
+// Comment describes the selections the variable toggles, for the comment of the generated method
+func (c *Condition) Comment() string {
+	return templates.ToGoPrivate(c.Variable) + " " + c.Directive + "s " + strings.Join(c.Paths, ", ") + " when true"
+}
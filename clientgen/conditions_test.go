@@ -0,0 +1,38 @@
+package clientgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestOperationConditions(t *testing.T) {
+	t.Parallel()
+	schema := gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: `
+		type Query { viewer: User }
+		type User { id: ID! name: String! posts: [Post!]! }
+		type Post { id: ID! title: String! }
+	`})
+	query, errs := gqlparser.LoadQuery(schema, `
+		query GetViewer($withPosts: Boolean!, $brief: Boolean = false) {
+			me: viewer {
+				id
+				name @skip(if: $brief)
+				posts @include(if: $withPosts) { id title @skip(if: $brief) }
+				...PostIDs @include(if: $withPosts)
+				... on User @include(if: true) { id }
+			}
+		}
+		fragment PostIDs on User { posts { id } }
+	`)
+	require.Nil(t, errs)
+
+	conditions := operationConditions(query.Operations[0])
+	require.Equal(t, []*Condition{
+		{Variable: "brief", Directive: "skip", Paths: []string{"me.name", "me.posts.title"}},
+		{Variable: "withPosts", Directive: "include", Paths: []string{"me.posts", "me ...PostIDs"}},
+	}, conditions)
+	require.Equal(t, "brief skips me.name, me.posts.title when true", conditions[0].Comment())
+}
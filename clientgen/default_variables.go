@@ -0,0 +1,113 @@
+package clientgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/xerrors"
+)
+
+// defaultVariables returns the JSON encoded default values configured for the nullable variables of
+// the operation, generated methods send them when the argument is nil
+func defaultVariables(operation *ast.OperationDefinition, args []*Argument, values map[string]interface{}) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	defaults := make(map[string]string, len(values))
+	for _, name := range names {
+		definition := operation.VariableDefinitions.ForName(name)
+		if definition == nil {
+			return nil, xerrors.Errorf("default variable %s: operation %s has no such variable", name, operation.Name)
+		}
+		if definition.Type.NonNull || !isNillable(argumentType(args, name)) {
+			return nil, xerrors.Errorf("default variable %s: variable of operation %s is not nullable", name, operation.Name)
+		}
+
+		value, err := json.Marshal(jsonValue(values[name]))
+		if err != nil {
+			return nil, xerrors.Errorf("default variable %s of operation %s: %w", name, operation.Name, err)
+		}
+
+		defaults[name] = string(value)
+	}
+
+	return defaults, nil
+}
+
+func argumentType(args []*Argument, variable string) types.Type {
+	for _, arg := range args {
+		if arg.Variable == variable {
+			return arg.Type
+		}
+	}
+
+	return nil
+}
+
+func isNillable(typ types.Type) bool {
+	switch typ.(type) {
+	case *types.Pointer, *types.Slice:
+		return true
+	}
+
+	return false
+}
+
+// jsonValue converts the maps decoded from yaml to maps with string keys
+func jsonValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			converted[fmt.Sprint(k)] = jsonValue(v)
+		}
+
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, v := range value {
+			converted[i] = jsonValue(v)
+		}
+
+		return converted
+	}
+
+	return value
+}
+
+// requiredVariables returns the non-null variables of the operation whose Go type can be nil, such as lists,
+// generated methods refuse to send them unset
+func requiredVariables(operation *ast.OperationDefinition, args []*Argument) []string {
+	var required []string
+	for _, definition := range operation.VariableDefinitions {
+		if definition.Type.NonNull && canBeNil(argumentType(args, definition.Variable)) {
+			required = append(required, definition.Variable)
+		}
+	}
+
+	return required
+}
+
+// canBeNil reports whether nil is a value of typ, named types included
+func canBeNil(typ types.Type) bool {
+	if typ == nil {
+		return false
+	}
+
+	switch typ.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Interface:
+		return true
+	}
+
+	return false
+}
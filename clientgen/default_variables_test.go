@@ -0,0 +1,31 @@
+package clientgen
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestRequiredVariables(t *testing.T) {
+	t.Parallel()
+	str := types.Typ[types.String]
+	operation := &ast.OperationDefinition{
+		Name: "Users",
+		VariableDefinitions: ast.VariableDefinitionList{
+			{Variable: "ids", Type: ast.NonNullListType(ast.NonNullNamedType("ID", nil), nil)},
+			{Variable: "name", Type: ast.NonNullNamedType("String", nil)},
+			{Variable: "after", Type: ast.NamedType("String", nil)},
+			{Variable: "filter", Type: ast.NonNullNamedType("JSON", nil)},
+		},
+	}
+	args := []*Argument{
+		{Variable: "ids", Type: types.NewSlice(str)},
+		{Variable: "name", Type: str},
+		{Variable: "after", Type: types.NewPointer(str)},
+		{Variable: "filter", Type: types.NewNamed(types.NewTypeName(0, nil, "JSON", nil), types.NewSlice(types.Typ[types.Byte]), nil)},
+	}
+
+	require.Equal(t, []string{"ids", "filter"}, requiredVariables(operation, args))
+}
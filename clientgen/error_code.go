@@ -0,0 +1,52 @@
+package clientgen
+
+import (
+	"go/types"
+
+	"github.com/99designs/gqlgen/codegen/config"
+	"github.com/99designs/gqlgen/codegen/templates"
+	gqlgencConfig "github.com/perchcredit/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/xerrors"
+)
+
+// ErrorCodeEnum is the schema enum of the codes the server sets in the extensions of its graphql errors
+type ErrorCodeEnum struct {
+	// Name is the Go name of the enum
+	Name   string
+	Type   types.Type
+	Values []string
+}
+
+// errorCodeEnum returns the enum configured in generateConfig.ErrorCodeEnum, nil when none is
+func errorCodeEnum(cfg *config.Config, generateConfig *gqlgencConfig.GenerateConfig) (*ErrorCodeEnum, error) {
+	if generateConfig == nil || generateConfig.ErrorCodeEnum == "" {
+		return nil, nil
+	}
+
+	name := generateConfig.ErrorCodeEnum
+	definition := cfg.Schema.Types[name]
+	if definition == nil || definition.Kind != ast.Enum {
+		return nil, xerrors.Errorf("errorCodeEnum %s is not an enum of the schema", name)
+	}
+
+	model, ok := cfg.Models[name]
+	if !ok || len(model.Model) == 0 {
+		return nil, xerrors.Errorf("errorCodeEnum %s has no Go type, generate the models or map it in models", name)
+	}
+
+	typ, err := cfg.NewBinder().FindTypeFromName(model.Model[0])
+	if err != nil {
+		return nil, xerrors.Errorf("errorCodeEnum %s: %w", name, err)
+	}
+
+	enum := &ErrorCodeEnum{
+		Name: templates.ToGo(name),
+		Type: typ,
+	}
+	for _, value := range definition.EnumValues {
+		enum.Values = append(enum.Values, value.Name)
+	}
+
+	return enum, nil
+}
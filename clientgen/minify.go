@@ -0,0 +1,41 @@
+package clientgen
+
+import (
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/lexer"
+)
+
+// minifyQuery strips the comments and the whitespace not separating two names, numbers or strings,
+// the query is returned unchanged when it cannot be tokenized
+func minifyQuery(query string) string {
+	src := []rune(query)
+	lex := lexer.New(&ast.Source{Input: query})
+
+	var buf strings.Builder
+	prev := lexer.Invalid
+	for {
+		tok, err := lex.ReadToken()
+		if err != nil {
+			return query
+		}
+		if tok.Kind == lexer.EOF {
+			return buf.String()
+		}
+
+		if (isWordToken(prev) && isWordToken(tok.Kind)) || (isStringToken(prev) && isStringToken(tok.Kind)) {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(string(src[tok.Pos.Start:tok.Pos.End]))
+		prev = tok.Kind
+	}
+}
+
+func isWordToken(kind lexer.Type) bool {
+	return kind == lexer.Name || kind == lexer.Int || kind == lexer.Float
+}
+
+func isStringToken(kind lexer.Type) bool {
+	return kind == lexer.String || kind == lexer.BlockString
+}
@@ -0,0 +1,124 @@
+package clientgen
+
+import (
+	"go/types"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// PaginationField is a top-level Relay connection field paginated forward with a cursor variable
+type PaginationField struct {
+	// Name is the Go name of the connection field
+	Name     string
+	Nullable bool
+	// EdgeNullable is set when the edges are pointers
+	EdgeNullable bool
+	NodeType     types.Type
+	// PageInfoType is the type of the pageInfo field of the connection
+	PageInfoType types.Type
+	// Cursor is the nullable variable passed to the after argument of the connection field
+	Cursor            string
+	EndCursorNullable bool
+}
+
+// paginationField returns the connection to paginate when the query selects a single top-level field
+// with edges { node } and pageInfo { hasNextPage endCursor }, its after argument set by a nullable variable
+func (s *Source) paginationField(operation *ast.OperationDefinition, args []*Argument) *PaginationField {
+	if operation.Operation != ast.Query || len(operation.SelectionSet) != 1 {
+		return nil
+	}
+
+	field, ok := operation.SelectionSet[0].(*ast.Field)
+	if !ok {
+		return nil
+	}
+
+	after := field.Arguments.ForName("after")
+	if after == nil || after.Value.Kind != ast.Variable {
+		return nil
+	}
+
+	var cursorType types.Type
+	for _, arg := range args {
+		if arg.Variable == after.Value.Raw {
+			cursorType = arg.Type
+		}
+	}
+	if _, ok := cursorType.(*types.Pointer); !ok {
+		return nil
+	}
+
+	pagination := &PaginationField{
+		Name:   templates.ToGo(field.Alias),
+		Cursor: after.Value.Raw,
+	}
+
+	responseField := s.sourceGenerator.NewResponseField(field)
+	connection, nullable := structField(responseField.Type)
+	if connection == nil {
+		return nil
+	}
+	pagination.Nullable = nullable
+
+	edges, ok := fieldType(connection, "Edges").(*types.Slice)
+	if !ok {
+		return nil
+	}
+	edge, edgeNullable := structField(edges.Elem())
+	if edge == nil || fieldType(edge, "Node") == nil {
+		return nil
+	}
+	pagination.EdgeNullable = edgeNullable
+	pagination.NodeType = fieldType(edge, "Node")
+
+	pageInfo, pageInfoNullable := structField(fieldType(connection, "PageInfo"))
+	if pageInfo == nil || pageInfoNullable || fieldType(pageInfo, "HasNextPage") == nil {
+		return nil
+	}
+	pagination.PageInfoType = fieldType(connection, "PageInfo")
+
+	switch endCursor := fieldType(pageInfo, "EndCursor").(type) {
+	case *types.Pointer:
+		pagination.EndCursorNullable = true
+	case nil:
+		return nil
+	default:
+		if !types.Identical(endCursor, cursorType.(*types.Pointer).Elem()) {
+			return nil
+		}
+	}
+
+	return pagination
+}
+
+// structField returns the struct of a field type and whether the field is nullable
+func structField(typ types.Type) (*types.Struct, bool) {
+	nullable := false
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+		nullable = true
+	}
+
+	st, ok := typ.(*types.Struct)
+	if !ok {
+		return nil, false
+	}
+
+	return st, nullable
+}
+
+// fieldType returns the type of the named field of st, nil when st has no such field
+func fieldType(st *types.Struct, name string) types.Type {
+	if st == nil {
+		return nil
+	}
+
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i).Name() == name {
+			return st.Field(i).Type()
+		}
+	}
+
+	return nil
+}
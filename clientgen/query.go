@@ -15,6 +15,10 @@ func ParseQueryDocuments(schema *ast.Schema, querySources []*ast.Source) (*ast.Q
 			return nil, xerrors.Errorf(": %w", gqlerr)
 		}
 
+		if err := checkOperationNames(querySource, query.Operations); err != nil {
+			return nil, err
+		}
+
 		mergeQueryDocument(&queryDocument, query)
 	}
 
@@ -25,6 +29,24 @@ func ParseQueryDocuments(schema *ast.Schema, querySources []*ast.Source) (*ast.Q
 	return &queryDocument, nil
 }
 
+// checkOperationNames rejects anonymous operations, the generated methods and the operationName
+// sent to select the operation to execute are the operation name
+func checkOperationNames(querySource *ast.Source, operations ast.OperationList) error {
+	for _, operation := range operations {
+		if operation.Name != "" {
+			continue
+		}
+
+		if len(operations) > 1 {
+			return xerrors.Errorf("%s:%d: anonymous operation in a document with %d operations, name every operation", querySource.Name, operation.Position.Line, len(operations))
+		}
+
+		return xerrors.Errorf("%s:%d: anonymous operation, name the operation", querySource.Name, operation.Position.Line)
+	}
+
+	return nil
+}
+
 func mergeQueryDocument(q, other *ast.QueryDocument) {
 	q.Operations = append(q.Operations, other.Operations...)
 	q.Fragments = append(q.Fragments, other.Fragments...)
@@ -58,14 +80,17 @@ func fragmentsInOperationDefinition(operation *ast.OperationDefinition) ast.Frag
 	return uniqueFragments
 }
 
+// fragmentsUnique removes the duplicated fragments, keeping the order of their first spread
+// so the generated operations do not change between generations
 func fragmentsUnique(fragments ast.FragmentDefinitionList) ast.FragmentDefinitionList {
-	uniqueMap := make(map[string]*ast.FragmentDefinition)
+	seen := make(map[string]bool, len(fragments))
+	uniqueFragments := make(ast.FragmentDefinitionList, 0, len(fragments))
 	for _, fragment := range fragments {
-		uniqueMap[fragment.Name] = fragment
-	}
+		if seen[fragment.Name] {
+			continue
+		}
 
-	uniqueFragments := make(ast.FragmentDefinitionList, 0, len(uniqueMap))
-	for _, fragment := range uniqueMap {
+		seen[fragment.Name] = true
 		uniqueFragments = append(uniqueFragments, fragment)
 	}
 
@@ -41,19 +41,73 @@ var path2regex = strings.NewReplacer(
 	`/`, `[\\/]`,
 )
 
+// queryFileExtensions are the extensions of the query files loaded from a directory
+var queryFileExtensions = []string{".graphql", ".gql"}
+
+func isQueryFile(path string) bool {
+	for _, extension := range queryFileExtensions {
+		if strings.EqualFold(filepath.Ext(path), extension) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expandBraces expands the first {a,b} alternatives of a glob pattern recursively,
+// queries/*.{graphql,gql} matching both extensions for instance
+func expandBraces(pattern string) []string {
+	start := strings.Index(pattern, "{")
+	if start == -1 {
+		return []string{pattern}
+	}
+
+	end := strings.Index(pattern[start:], "}")
+	if end == -1 {
+		return []string{pattern}
+	}
+	end += start
+
+	var patterns []string
+	for _, alternative := range strings.Split(pattern[start+1:end], ",") {
+		patterns = append(patterns, expandBraces(pattern[:start]+alternative+pattern[end+1:])...)
+	}
+
+	return patterns
+}
+
 // LoadQuerySourceなどは、gqlgenがLoadConfigでSchemaを読み込む時の実装をコピーして一部修正している
 // **/test/*.graphqlなどに対応している
+// A query directory loads its .graphql and .gql files, and {a,b} alternatives are expanded
 func LoadQuerySources(queryFileNames []string) ([]*ast.Source, error) {
 	var noGlobQueryFileNames config.StringList
 
 	var err error
-	preGlobbing := queryFileNames
+	var preGlobbing []string
+	for _, f := range queryFileNames {
+		preGlobbing = append(preGlobbing, expandBraces(f)...)
+	}
 	for _, f := range preGlobbing {
 		var matches []string
 
-		// for ** we want to override default globbing patterns and walk all
-		// subdirectories to match schema files.
-		if strings.Contains(f, "**") {
+		// a directory holds the query files with any of the queryFileExtensions below it
+		if info, statErr := os.Stat(f); statErr == nil && info.IsDir() {
+			if err := filepath.Walk(f, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+
+				if !info.IsDir() && isQueryFile(path) {
+					matches = append(matches, path)
+				}
+
+				return nil
+			}); err != nil {
+				return nil, xerrors.Errorf("failed to walk query directory %s: %w", f, err)
+			}
+		} else if strings.Contains(f, "**") {
+			// for ** we want to override default globbing patterns and walk all
+			// subdirectories to match schema files.
 			pathParts := strings.SplitN(f, "**", 2)
 			rest := strings.TrimPrefix(strings.TrimPrefix(pathParts[1], `\`), `/`)
 			// turn the rest of the glob into a regex, anchored only at the end because ** allows
@@ -65,7 +119,7 @@ func LoadQuerySources(queryFileNames []string) ([]*ast.Source, error) {
 					return err
 				}
 
-				if globRe.MatchString(strings.TrimPrefix(path, pathParts[0])) {
+				if !info.IsDir() && globRe.MatchString(strings.TrimPrefix(path, pathParts[0])) {
 					matches = append(matches, path)
 				}
 
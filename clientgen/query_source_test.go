@@ -0,0 +1,46 @@
+package clientgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadQuerySources(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, name := range []string{"user.graphql", "team.gql", "nested/org.GQL", "notes.txt"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("query "+name+" { viewer }"), 0o600))
+	}
+	load := func(patterns ...string) []string {
+		sources, err := LoadQuerySources(patterns)
+		require.NoError(t, err)
+
+		names := make([]string, 0, len(sources))
+		for _, source := range sources {
+			rel, err := filepath.Rel(dir, source.Name)
+			require.NoError(t, err)
+			names = append(names, filepath.ToSlash(rel))
+		}
+
+		return names
+	}
+
+	require.Equal(t, []string{"team.gql"}, load(filepath.Join(dir, "*.gql")))
+	require.Equal(t, []string{"user.graphql", "team.gql"}, load(filepath.Join(dir, "*.{graphql,gql}")))
+	require.Equal(t, []string{"nested/org.GQL", "team.gql", "user.graphql"}, load(dir))
+	require.Equal(t, []string{"nested/org.GQL"}, load(filepath.Join(dir, "**", "*.GQL")))
+	require.Equal(t, []string{"team.gql", "user.graphql"}, load(filepath.Join(dir, "*.gql"), filepath.Join(dir, "*.graphql"), filepath.Join(dir, "*.gql")))
+}
+
+func TestExpandBraces(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, []string{"q/*.graphql"}, expandBraces("q/*.graphql"))
+	require.Equal(t, []string{"q/*.graphql", "q/*.gql"}, expandBraces("q/*.{graphql,gql}"))
+	require.Equal(t, []string{"a/x.gql", "a/y.gql", "b/x.gql", "b/y.gql"}, expandBraces("{a,b}/{x,y}.gql"))
+	require.Equal(t, []string{"q/{graphql"}, expandBraces("q/{graphql"))
+}
@@ -0,0 +1,147 @@
+package clientgen
+
+import (
+	"go/types"
+	"strconv"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// SchemaDefault is the default value of a variable, declared by the operation or by the schema argument
+// the variable is passed to
+type SchemaDefault struct {
+	Variable string
+	// Const is the name of the generated constant holding Value
+	Const string
+	// Value is the Go literal of the default value
+	Value string
+	// Type is the type of the variable without pointer, Value is converted to it when Named
+	Type  types.Type
+	Named bool
+	// Pointer is set when the variable is nullable, the generated methods send the default for nil
+	Pointer bool
+}
+
+// schemaDefaults returns the scalar and enum default values of the variables of the operation, the variables
+// passed to arguments of different defaults have none
+func schemaDefaults(operation *ast.OperationDefinition, args []*Argument) []*SchemaDefault {
+	argumentDefaults := variableArgumentDefaults(operation)
+
+	var defaults []*SchemaDefault
+	for _, definition := range operation.VariableDefinitions {
+		value := definition.DefaultValue
+		if value == nil {
+			value = argumentDefaults[definition.Variable]
+		}
+		if value == nil {
+			continue
+		}
+
+		typ := argumentType(args, definition.Variable)
+		pointer, ok := typ.(*types.Pointer)
+		if ok {
+			typ = pointer.Elem()
+		}
+
+		literal, named := DefaultLiteral(value, typ)
+		if literal == "" {
+			continue
+		}
+
+		defaults = append(defaults, &SchemaDefault{
+			Variable: definition.Variable,
+			Const:    templates.ToGo(operation.Name) + templates.ToGo(definition.Variable) + "Default",
+			Value:    literal,
+			Type:     typ,
+			Named:    named,
+			Pointer:  ok,
+		})
+	}
+
+	return defaults
+}
+
+// variableArgumentDefaults returns the defaults of the arguments the variables are passed to as a whole
+func variableArgumentDefaults(operation *ast.OperationDefinition) map[string]*ast.Value {
+	defaults := make(map[string]*ast.Value)
+	conflicting := make(map[string]bool)
+	var walk func(selectionSet ast.SelectionSet, fragments map[string]bool)
+	walk = func(selectionSet ast.SelectionSet, fragments map[string]bool) {
+		for _, selection := range selectionSet {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				for _, arg := range selection.Arguments {
+					if arg.Value == nil || arg.Value.Kind != ast.Variable || selection.Definition == nil {
+						continue
+					}
+
+					definition := selection.Definition.Arguments.ForName(arg.Name)
+					if definition == nil || definition.DefaultValue == nil {
+						continue
+					}
+
+					variable := arg.Value.Raw
+					if other, ok := defaults[variable]; ok && other.String() != definition.DefaultValue.String() {
+						conflicting[variable] = true
+					}
+					defaults[variable] = definition.DefaultValue
+				}
+				walk(selection.SelectionSet, fragments)
+			case *ast.FragmentSpread:
+				if selection.Definition != nil && !fragments[selection.Name] {
+					walk(selection.Definition.SelectionSet, copyFragments(fragments, selection.Name))
+				}
+			case *ast.InlineFragment:
+				walk(selection.SelectionSet, fragments)
+			}
+		}
+	}
+	walk(operation.SelectionSet, map[string]bool{})
+
+	for variable := range conflicting {
+		delete(defaults, variable)
+	}
+
+	return defaults
+}
+
+// DefaultLiteral returns the Go literal of a scalar or enum value of type typ, empty when the value is not
+// a literal of the underlying basic type of typ, and whether typ is a named type to convert the literal to
+func DefaultLiteral(value *ast.Value, typ types.Type) (string, bool) {
+	if typ == nil {
+		return "", false
+	}
+
+	basic, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return "", false
+	}
+	_, named := typ.(*types.Named)
+
+	info := basic.Info()
+	switch value.Kind {
+	case ast.IntValue:
+		if info&types.IsNumeric == 0 || info&types.IsComplex != 0 {
+			return "", false
+		}
+	case ast.FloatValue:
+		if info&types.IsFloat == 0 {
+			return "", false
+		}
+	case ast.BooleanValue:
+		if info&types.IsBoolean == 0 {
+			return "", false
+		}
+	case ast.StringValue, ast.BlockValue, ast.EnumValue:
+		if info&types.IsString == 0 {
+			return "", false
+		}
+
+		return strconv.Quote(value.Raw), named
+	default:
+		return "", false
+	}
+
+	return value.Raw, named
+}
@@ -0,0 +1,54 @@
+package clientgen
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestSchemaDefaults(t *testing.T) {
+	t.Parallel()
+	schema := gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: `
+		enum Role { ADMIN MEMBER }
+		type Query {
+			users(first: Int = 10, role: Role = ADMIN, ratio: Float = 0.5): [User!]!
+			admins(first: Int = 5): [User!]!
+			user(id: ID!): User
+		}
+		type User {
+			id: ID!
+			friends(first: Int = 3, label: String = "all"): [User!]!
+		}
+	`})
+	query, errs := gqlparser.LoadQuery(schema, `
+		query Users($first: Int, $role: Role, $ratio: Float, $label: String, $id: ID = "1", $flag: Boolean! = true) {
+			users(first: $first, role: $role, ratio: $ratio) { id ...Friends }
+			admins(first: $first) { id }
+			user(id: $id) { id @include(if: $flag) }
+		}
+		fragment Friends on User {
+			friends(first: 2, label: $label) { id }
+		}
+	`)
+	require.Nil(t, errs)
+
+	role := types.NewNamed(types.NewTypeName(0, types.NewPackage("example.com/gen", "gen"), "Role", nil), types.Typ[types.String], nil)
+	args := []*Argument{
+		{Variable: "first", Type: types.NewPointer(types.Typ[types.Int])},
+		{Variable: "role", Type: types.NewPointer(role)},
+		{Variable: "ratio", Type: types.NewPointer(types.Typ[types.Int])},
+		{Variable: "label", Type: types.NewPointer(types.Typ[types.String])},
+		{Variable: "id", Type: types.NewPointer(types.Typ[types.String])},
+		{Variable: "flag", Type: types.Typ[types.Bool]},
+	}
+
+	require.Equal(t, []*SchemaDefault{
+		{Variable: "role", Const: "UsersRoleDefault", Value: `"ADMIN"`, Type: role, Named: true, Pointer: true},
+		{Variable: "label", Const: "UsersLabelDefault", Value: `"all"`, Type: types.Typ[types.String], Pointer: true},
+		{Variable: "id", Const: "UsersIDDefault", Value: `"1"`, Type: types.Typ[types.String], Pointer: true},
+		{Variable: "flag", Const: "UsersFlagDefault", Value: "true", Type: types.Typ[types.Bool]},
+	}, schemaDefaults(query.Operations[0], args))
+}
@@ -2,8 +2,13 @@ package clientgen
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/99designs/gqlgen/codegen/templates"
 	"github.com/perchcredit/gqlgenc/config"
@@ -61,24 +66,65 @@ func (s *Source) Fragments() ([]*Fragment, error) {
 }
 
 type Operation struct {
-	Name                string
-	ResponseStructName  string
-	Operation           string
+	Name               string
+	ResponseStructName string
+	Operation          string
+	// ReadableOperation is the formatted operation when Operation is minified
+	ReadableOperation string
+	// Hash is the hex encoded sha256 of Operation
+	Hash string
+	// HashHeader is the header sending Hash, not sent when empty
+	HashHeader          string
 	Args                []*Argument
 	VariableDefinitions ast.VariableDefinitionList
+	// Stream is the top-level list field decoded element by element, nil when not streamable
+	Stream *StreamField
+	// Pagination is the top-level connection field iterated page by page, nil when not paginated
+	Pagination *PaginationField
+	// DefaultVariables are the JSON encoded values sent for the nullable variables left nil
+	DefaultVariables map[string]string
+	// RequiredVariables are the non-null variables of nillable types the generated methods check are set,
+	// only computed with GenerateConfig.ValidateVariables
+	RequiredVariables []string
+	// SchemaDefaults are the default values of the variables, only computed with GenerateConfig.SchemaDefaults
+	SchemaDefaults []*SchemaDefault
+	// Conditions are the variables of the @include and @skip directives, commented on the generated method
+	Conditions []*Condition
+	// Complexity is the estimated cost of the operation, only computed with GenerateConfig.Complexity
+	Complexity int
+	// Source is the file:line the operation is declared at, commented on the generated methods when set
+	Source string
+	// Prefetch generates Prefetch<Operation>, set for the queries with GenerateConfig.PrefetchMethods
+	Prefetch bool
+}
+
+// StreamField is a top-level list field of an operation response
+type StreamField struct {
+	Name        string
+	ElementType types.Type
 }
 
 func NewOperation(operation *ast.OperationDefinition, queryDocument *ast.QueryDocument, args []*Argument, generateConfig *config.GenerateConfig) *Operation {
+	query := queryString(queryDocument)
+	var readableQuery string
+	if generateConfig != nil && generateConfig.MinifyQueries {
+		readableQuery, query = query, minifyQuery(query)
+	}
+	hash := sha256.Sum256([]byte(query))
+
 	return &Operation{
 		Name:                operation.Name,
 		ResponseStructName:  getResponseStructName(operation, generateConfig),
-		Operation:           queryString(queryDocument),
+		Operation:           query,
+		ReadableOperation:   readableQuery,
+		Hash:                hex.EncodeToString(hash[:]),
 		Args:                args,
 		VariableDefinitions: operation.VariableDefinitions,
+		Conditions:          operationConditions(operation),
 	}
 }
 
-func (s *Source) Operations(queryDocuments []*ast.QueryDocument) []*Operation {
+func (s *Source) Operations(queryDocuments []*ast.QueryDocument) ([]*Operation, error) {
 	operations := make([]*Operation, 0, len(s.queryDocument.Operations))
 
 	queryDocumentsMap := queryDocumentMapByOperationName(queryDocuments)
@@ -86,15 +132,104 @@ func (s *Source) Operations(queryDocuments []*ast.QueryDocument) []*Operation {
 	for _, operation := range s.queryDocument.Operations {
 		queryDocument := queryDocumentsMap[operation.Name]
 		args := operationArgsMap[operation.Name]
-		operations = append(operations, NewOperation(
+		op := NewOperation(
 			operation,
 			queryDocument,
 			args,
 			s.generateConfig,
-		))
+		)
+		if s.generateConfig != nil && s.generateConfig.Streaming {
+			op.Stream = s.streamField(operation)
+		}
+		if s.generateConfig != nil && s.generateConfig.Pagination {
+			op.Pagination = s.paginationField(operation, args)
+		}
+		if err := configureOperation(op, operation, args, s.generateConfig); err != nil {
+			return nil, err
+		}
+
+		operations = append(operations, op)
 	}
 
-	return operations
+	return operations, nil
+}
+
+// configureOperation sets the fields of op computed from the operation and its arguments for generateConfig
+func configureOperation(op *Operation, operation *ast.OperationDefinition, args []*Argument, generateConfig *config.GenerateConfig) error {
+	if generateConfig == nil {
+		return nil
+	}
+
+	if generateConfig.FreeFunctions && templates.ToGo(op.Name) == templates.ToGo(op.ResponseStructName) {
+		return xerrors.Errorf("freeFunctions: the function %s is named like its response struct, set a prefix or suffix for the %s response structs", templates.ToGo(op.Name), operation.Operation)
+	}
+	op.Prefetch = generateConfig.PrefetchMethods && operation.Operation == ast.Query
+	op.HashHeader = generateConfig.OperationHashHeader
+	if generateConfig.SourceComments {
+		op.Source = operationSource(operation)
+	}
+
+	defaults, err := defaultVariables(operation, args, generateConfig.DefaultVariables[operation.Name])
+	if err != nil {
+		return err
+	}
+	op.DefaultVariables = defaults
+	if generateConfig.Complexity != nil {
+		op.Complexity = complexity(operation, generateConfig.Complexity)
+	}
+	if generateConfig.ValidateVariables {
+		op.RequiredVariables = requiredVariables(operation, args)
+	}
+	if generateConfig.SchemaDefaults {
+		op.SchemaDefaults = schemaDefaults(operation, args)
+	}
+
+	return nil
+}
+
+// operationSource returns the file:line of the operation, the file relative to the working directory when possible
+func operationSource(operation *ast.OperationDefinition) string {
+	if operation.Position == nil || operation.Position.Src == nil {
+		return ""
+	}
+
+	name := operation.Position.Src.Name
+	if filepath.IsAbs(name) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, name); err == nil && !strings.HasPrefix(rel, "..") {
+				name = rel
+			}
+		}
+	}
+
+	return fmt.Sprintf("%s:%d", filepath.ToSlash(name), operation.Position.Line)
+}
+
+// streamField returns the field to stream when the operation selects a single top-level list field
+func (s *Source) streamField(operation *ast.OperationDefinition) *StreamField {
+	if operation.Operation != ast.Query {
+		return nil
+	}
+
+	responseFields := s.sourceGenerator.NewResponseFields(operation.SelectionSet)
+	if len(responseFields) != 1 || responseFields[0].IsFragmentSpread || responseFields[0].IsInlineFragment {
+		return nil
+	}
+
+	typ := responseFields[0].Type
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+
+	slice, ok := typ.(*types.Slice)
+	if !ok {
+		return nil
+	}
+
+	return &StreamField{
+		Name:        responseFields[0].Name,
+		ElementType: slice.Elem(),
+	}
 }
 
 func (s *Source) operationArgsMapByOperationName() map[string][]*Argument {
@@ -125,8 +260,9 @@ func queryString(queryDocument *ast.QueryDocument) string {
 }
 
 type OperationResponse struct {
-	Name string
-	Type types.Type
+	Name      string
+	Type      types.Type
+	Accessors []*Accessor
 }
 
 func (s *Source) OperationResponses() ([]*OperationResponse, error) {
@@ -137,10 +273,14 @@ func (s *Source) OperationResponses() ([]*OperationResponse, error) {
 		if s.sourceGenerator.cfg.Models.Exists(name) {
 			return nil, xerrors.New(fmt.Sprintf("%s is duplicated", name))
 		}
-		operationResponse = append(operationResponse, &OperationResponse{
+		response := &OperationResponse{
 			Name: name,
 			Type: responseFields.StructType(),
-		})
+		}
+		if s.generateConfig != nil && s.generateConfig.Accessors {
+			response.Accessors = accessors(response.Type, s.sourceGenerator.client.ImportPath())
+		}
+		operationResponse = append(operationResponse, response)
 	}
 
 	for _, operationResponse := range operationResponse {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/99designs/gqlgen/codegen/config"
 	"github.com/99designs/gqlgen/codegen/templates"
+	gqlgencConfig "github.com/perchcredit/gqlgenc/config"
 	"github.com/vektah/gqlparser/v2/ast"
 	"golang.org/x/xerrors"
 )
@@ -64,16 +65,18 @@ func (rs ResponseFieldList) IsStructType() bool {
 }
 
 type SourceGenerator struct {
-	cfg    *config.Config
-	binder *config.Binder
-	client config.PackageConfig
+	cfg            *config.Config
+	binder         *config.Binder
+	client         config.PackageConfig
+	generateConfig *gqlgencConfig.GenerateConfig
 }
 
-func NewSourceGenerator(cfg *config.Config, client config.PackageConfig) *SourceGenerator {
+func NewSourceGenerator(cfg *config.Config, client config.PackageConfig, generateConfig *gqlgencConfig.GenerateConfig) *SourceGenerator {
 	return &SourceGenerator{
-		cfg:    cfg,
-		binder: cfg.NewBinder(),
-		client: client,
+		cfg:            cfg,
+		binder:         cfg.NewBinder(),
+		client:         client,
+		generateConfig: generateConfig,
 	}
 }
 
@@ -160,10 +163,18 @@ func (r *SourceGenerator) NewResponseField(selection ast.Selection) *ResponseFie
 		// GraphQLの定義がオプショナルのはtypeのポインタ型が返り、配列の定義場合はポインタのスライスの型になって返ってきます
 		// return pointer type then optional type or slice pointer then slice type of definition in GraphQL.
 		typ := r.binder.CopyModifiersFromAst(selection.Definition.Type, baseType)
+		if selection.Name == "__typename" {
+			// the parser declares __typename nullable, it is a String! naming the concrete type of the object
+			typ = baseType
+		}
 
+		key := selection.Alias
+		if selection.Alias == selection.Name && selection.ObjectDefinition != nil {
+			key = r.generateConfig.ResponseKey(selection.ObjectDefinition.Name, selection.Name)
+		}
 		tags := []string{
-			fmt.Sprintf(`json:"%s"`, selection.Alias),
-			fmt.Sprintf(`graphql:"%s"`, selection.Alias),
+			fmt.Sprintf(`json:"%s"`, key),
+			fmt.Sprintf(`graphql:"%s"`, key),
 		}
 
 		return &ResponseField{
@@ -3,10 +3,22 @@ package clientgen
 import (
 	"github.com/99designs/gqlgen/codegen/config"
 	"github.com/99designs/gqlgen/codegen/templates"
+	gqlgencClient "github.com/perchcredit/gqlgenc/client"
+	gqlgencConfig "github.com/perchcredit/gqlgenc/config"
 	"golang.org/x/xerrors"
 )
 
-func RenderTemplate(cfg *config.Config, query *Query, mutation *Mutation, fragments []*Fragment, operations []*Operation, operationResponses []*OperationResponse, client config.PackageConfig) error {
+func RenderTemplate(cfg *config.Config, query *Query, mutation *Mutation, fragments []*Fragment, operations []*Operation, operationResponses []*OperationResponse, client config.PackageConfig, generateConfig *gqlgencConfig.GenerateConfig) error {
+	var schemaHash string
+	if generateConfig != nil && generateConfig.SchemaHash {
+		schemaHash = gqlgencClient.SchemaHash(cfg.Schema)
+	}
+
+	errorCode, err := errorCodeEnum(cfg, generateConfig)
+	if err != nil {
+		return err
+	}
+
 	if err := templates.Render(templates.Options{
 		PackageName: client.Package,
 		Filename:    client.Filename,
@@ -16,6 +28,9 @@ func RenderTemplate(cfg *config.Config, query *Query, mutation *Mutation, fragme
 			"Fragment":          fragments,
 			"Operation":         operations,
 			"OperationResponse": operationResponses,
+			"GenerateConfig":    generateConfig,
+			"SchemaHash":        schemaHash,
+			"ErrorCode":         errorCode,
 		},
 		Packages:   cfg.Packages,
 		PackageDoc: "// Code generated by github.com/perchcredit/gqlgenc, DO NOT EDIT.\n",
@@ -0,0 +1,248 @@
+package clientgen
+
+import (
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/codegen/config"
+	gqlgencConfig "github.com/perchcredit/gqlgenc/config"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// templateSchema is the schema of the operations rendered by the template tests
+var templateSchema = gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: `
+	type Query {
+		users(first: Int, after: String): UserConnection!
+		user(id: ID!, tags: [String!]!, limit: Int = 10): User
+		names: [String!]!
+	}
+	type Mutation { setName(name: String!): String! }
+	type UserConnection { edges: [UserEdge!]! pageInfo: PageInfo! }
+	type UserEdge { node: User! }
+	type User { id: ID! name: String! friend: User }
+	type PageInfo { hasNextPage: Boolean! endCursor: String }
+`})
+
+// templateOperation parses the operation of src and sets its fields for generateConfig as the generator does
+func templateOperation(t *testing.T, src string, args []*Argument, response types.Type, generateConfig *gqlgencConfig.GenerateConfig) (*Operation, *OperationResponse) {
+	t.Helper()
+	query, gqlerr := parser.ParseQuery(&ast.Source{Name: "query.graphql", Input: src})
+	require.Nil(t, gqlerr)
+	require.Empty(t, validator.Validate(templateSchema, query))
+	operation := NewOperation(query.Operations[0], query, args, generateConfig)
+	require.NoError(t, configureOperation(operation, query.Operations[0], args, generateConfig))
+
+	return operation, &OperationResponse{Name: operation.ResponseStructName, Type: response}
+}
+
+// usersOperation is the Users query paginating the users connection with its after variable
+func usersOperation(t *testing.T, generateConfig *gqlgencConfig.GenerateConfig) (*Operation, *OperationResponse) {
+	t.Helper()
+	str := types.Typ[types.String]
+	node := types.NewStruct([]*types.Var{
+		types.NewField(0, nil, "ID", str, false),
+		types.NewField(0, nil, "Name", str, false),
+	}, []string{`json:"id" graphql:"id"`, `json:"name" graphql:"name"`})
+	edge := types.NewStruct([]*types.Var{
+		types.NewField(0, nil, "Node", node, false),
+	}, []string{`json:"node" graphql:"node"`})
+	pageInfo := types.NewStruct([]*types.Var{
+		types.NewField(0, nil, "HasNextPage", types.Typ[types.Bool], false),
+		types.NewField(0, nil, "EndCursor", types.NewPointer(str), false),
+	}, []string{`json:"hasNextPage" graphql:"hasNextPage"`, `json:"endCursor" graphql:"endCursor"`})
+	connection := types.NewStruct([]*types.Var{
+		types.NewField(0, nil, "Edges", types.NewSlice(edge), false),
+		types.NewField(0, nil, "PageInfo", pageInfo, false),
+	}, []string{`json:"edges" graphql:"edges"`, `json:"pageInfo" graphql:"pageInfo"`})
+	response := types.NewStruct([]*types.Var{
+		types.NewField(0, nil, "Users", connection, false),
+	}, []string{`json:"users" graphql:"users"`})
+
+	operation, operationResponse := templateOperation(t, `
+		query Users($first: Int, $after: String) {
+			users(first: $first, after: $after) { edges { node { id name } } pageInfo { hasNextPage endCursor } }
+		}
+	`, []*Argument{
+		{Variable: "first", Type: types.NewPointer(types.Typ[types.Int])},
+		{Variable: "after", Type: types.NewPointer(str)},
+	}, response, generateConfig)
+	operation.Pagination = &PaginationField{
+		Name:              "Users",
+		NodeType:          node,
+		PageInfoType:      pageInfo,
+		Cursor:            "after",
+		EndCursorNullable: true,
+	}
+
+	return operation, operationResponse
+}
+
+// newTemplateModule returns the directory of a module requiring gqlgenc from the working tree and the go binary,
+// it skips the tests which cannot build it
+func newTemplateModule(t *testing.T) (string, string) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds a module")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+
+	root, err := filepath.Abs("..")
+	require.NoError(t, err)
+	dir := t.TempDir()
+	goMod := "module example.com/generated\n\ngo 1.23\n\nrequire github.com/perchcredit/gqlgenc v0.0.0\n\nreplace github.com/perchcredit/gqlgenc => " + root + "\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0o644))
+	goSum, err := ioutil.ReadFile(filepath.Join(root, "go.sum"))
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0o644))
+
+	return dir, goBin
+}
+
+// renderTemplate renders the client of the operations into filename
+func renderTemplate(t *testing.T, filename, pkg string, operations []*Operation, responses []*OperationResponse, generateConfig *gqlgencConfig.GenerateConfig) {
+	t.Helper()
+	// the package cache of gqlgen is internal, its zero value looks the package names up
+	cfg := &config.Config{Schema: templateSchema}
+	packages := reflect.ValueOf(cfg).Elem().FieldByName("Packages")
+	packages.Set(reflect.New(packages.Type().Elem()))
+
+	empty := types.NewStruct(nil, nil)
+	require.NoError(t, RenderTemplate(
+		cfg,
+		&Query{Name: "Query", Type: empty},
+		&Mutation{Name: "Mutation", Type: empty},
+		nil,
+		operations,
+		responses,
+		config.PackageConfig{Filename: filename, Package: pkg},
+		generateConfig,
+	))
+}
+
+// runGo runs the go command in the module of dir
+func runGo(t *testing.T, goBin, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command(goBin, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+// TestGeneratedClient renders the client of the Users query with Execute into a module and runs testdata/generated
+// against it
+func TestGeneratedClient(t *testing.T) {
+	t.Parallel()
+	dir, goBin := newTemplateModule(t)
+	test, err := ioutil.ReadFile(filepath.Join("testdata", "generated", "client_test.go"))
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "client_test.go"), test, 0o644))
+
+	generateConfig := &gqlgencConfig.GenerateConfig{ExecuteMethod: true}
+	operation, response := usersOperation(t, generateConfig)
+	renderTemplate(t, filepath.Join(dir, "client.go"), "generated", []*Operation{operation}, []*OperationResponse{response}, generateConfig)
+
+	runGo(t, goBin, dir, "test", "-count=1", "-timeout=1m", "./...")
+}
+
+// TestGeneratedClientOptions renders the clients of every option of the template, with pointer and value
+// receivers, and vets them
+func TestGeneratedClientOptions(t *testing.T) {
+	t.Parallel()
+	dir, goBin := newTemplateModule(t)
+
+	for _, receivers := range []string{gqlgencConfig.PointerReceivers, gqlgencConfig.ValueReceivers} {
+		generateConfig := &gqlgencConfig.GenerateConfig{
+			Suffix:              &gqlgencConfig.NamingConfig{Query: "Payload", Mutation: "Payload"},
+			TimeLayout:          time.RFC3339,
+			Streaming:           true,
+			ClientInterfaceName: "Interface",
+			FakeClient:          true,
+			BackgroundMethods:   true,
+			Accessors:           true,
+			OperationHashHeader: "X-Operation-Hash",
+			OperationNameHeader: "X-Operation-Name",
+			MinifyQueries:       true,
+			Pagination:          true,
+			RawMethods:          true,
+			DefaultVariables:    map[string]map[string]interface{}{"User": {"limit": 20}},
+			FreeFunctions:       true,
+			WatchMethods:        true,
+			PrefetchMethods:     true,
+			BatchMethods:        true,
+			VariablesMethods:    true,
+			ValidateVariables:   true,
+			SchemaHash:          true,
+			SchemaDefaults:      true,
+			ExecuteMethod:       true,
+			OperationRegistry:   true,
+			SourceComments:      true,
+			Complexity:          &gqlgencConfig.ComplexityConfig{},
+			Receivers:           receivers,
+		}
+		str := types.Typ[types.String]
+
+		users, usersResponse := usersOperation(t, generateConfig)
+
+		friend := types.NewStruct([]*types.Var{
+			types.NewField(0, nil, "Name", str, false),
+		}, []string{`json:"name" graphql:"name"`})
+		user := types.NewStruct([]*types.Var{
+			types.NewField(0, nil, "ID", str, false),
+			types.NewField(0, nil, "Name", str, false),
+			types.NewField(0, nil, "Friend", types.NewPointer(friend), false),
+		}, []string{`json:"id" graphql:"id"`, `json:"name" graphql:"name"`, `json:"friend" graphql:"friend"`})
+		userOperation, userResponse := templateOperation(t, `
+			query User($id: ID!, $tags: [String!]!, $limit: Int) {
+				user(id: $id, tags: $tags, limit: $limit) { id name friend { name } }
+			}
+		`, []*Argument{
+			{Variable: "id", Type: str},
+			{Variable: "tags", Type: types.NewSlice(str)},
+			{Variable: "limit", Type: types.NewPointer(types.Typ[types.Int])},
+		}, types.NewStruct([]*types.Var{
+			types.NewField(0, nil, "User", types.NewPointer(user), false),
+		}, []string{`json:"user" graphql:"user"`}), generateConfig)
+
+		names, namesResponse := templateOperation(t, `query Names { names }`, nil, types.NewStruct([]*types.Var{
+			types.NewField(0, nil, "Names", types.NewSlice(str), false),
+		}, []string{`json:"names" graphql:"names"`}), generateConfig)
+		names.Stream = &StreamField{Name: "names", ElementType: str}
+
+		setName, setNameResponse := templateOperation(t, `mutation SetName($name: String!) { setName(name: $name) }`, []*Argument{
+			{Variable: "name", Type: str},
+		}, types.NewStruct([]*types.Var{
+			types.NewField(0, nil, "SetName", str, false),
+		}, []string{`json:"setName" graphql:"setName"`}), generateConfig)
+
+		responses := []*OperationResponse{usersResponse, userResponse, namesResponse, setNameResponse}
+		for _, response := range responses {
+			response.Accessors = accessors(response.Type, "example.com/generated/"+receivers)
+		}
+		// the options are all rendered
+		require.NotEmpty(t, userResponse.Accessors)
+		require.Equal(t, []string{"tags"}, userOperation.RequiredVariables)
+		require.NotEmpty(t, userOperation.SchemaDefaults)
+		require.NotEmpty(t, userOperation.DefaultVariables)
+		require.True(t, names.Prefetch)
+		require.False(t, setName.Prefetch)
+
+		require.NoError(t, os.Mkdir(filepath.Join(dir, receivers), 0o755))
+		renderTemplate(t, filepath.Join(dir, receivers, "client.go"), receivers, []*Operation{users, userOperation, names, setName}, responses, generateConfig)
+	}
+
+	runGo(t, goBin, dir, "vet", "./...")
+}
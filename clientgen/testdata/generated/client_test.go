@@ -0,0 +1,151 @@
+package generated
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/perchcredit/gqlgenc/client"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+// pages are the pages of the users connection by after cursor
+var pages = map[string]string{
+	"":  `{"data":{"users":{"edges":[{"node":{"id":"1","name":"a"}},{"node":{"id":"2","name":"b"}}],"pageInfo":{"hasNextPage":true,"endCursor":"2"}}}}`,
+	"2": `{"data":{"users":{"edges":[{"node":{"id":"3","name":"c"}}],"pageInfo":{"hasNextPage":false,"endCursor":"3"}}}}`,
+}
+
+// newClient returns a client of a server paginating the users connection and the after cursors it received
+func newClient(t *testing.T) (*Client, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var cursors []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req client.Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		cursor, _ := req.Variables["after"].(string)
+		mu.Lock()
+		cursors = append(cursors, cursor)
+		mu.Unlock()
+		page, ok := pages[cursor]
+		if fail, failing := r.Header["X-Fail"]; !ok || (failing && fail[0] == cursor) {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+		_, _ = w.Write([]byte(page))
+	}))
+	t.Cleanup(ts.Close)
+
+	return NewClient(ClientOptions{HTTPClient: ts.Client(), BaseURL: ts.URL}), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string(nil), cursors...)
+	}
+}
+
+func TestAll(t *testing.T) {
+	t.Parallel()
+	c, cursors := newClient(t)
+	var names []string
+	for node, err := range c.UsersAll(context.Background(), nil) {
+		require.NoError(t, err)
+		names = append(names, node.Name)
+	}
+	// the cursor advances to the end cursor of each page until hasNextPage is false
+	require.Equal(t, []string{"a", "b", "c"}, names)
+	require.Equal(t, []string{"", "2"}, cursors())
+
+	// breaking out of the loop stops requesting pages
+	c, cursors = newClient(t)
+	for node := range c.UsersAll(context.Background(), nil) {
+		require.Equal(t, "a", node.Name)
+
+		break
+	}
+	require.Equal(t, []string{""}, cursors())
+}
+
+func TestAllError(t *testing.T) {
+	t.Parallel()
+	c, cursors := newClient(t)
+	var names []string
+	var errs []error
+	// the server fails the second page of the calls sending X-Fail
+	for node, err := range c.UsersAll(context.Background(), nil, client.WithHeader("X-Fail", "2")) {
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+		names = append(names, node.Name)
+	}
+	// the iteration stops after yielding the error
+	require.Equal(t, []string{"a", "b"}, names)
+	require.Len(t, errs, 1)
+	require.Equal(t, []string{"", "2"}, cursors())
+}
+
+func TestPaginator(t *testing.T) {
+	t.Parallel()
+	c, cursors := newClient(t)
+	paginator := c.UsersPaginator(nil)
+	names := func() []string {
+		var names []string
+		for _, node := range paginator.Page() {
+			names = append(names, node.Name)
+		}
+
+		return names
+	}
+
+	ok, err := paginator.Next(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"a", "b"}, names())
+	require.True(t, paginator.PageInfo().HasNextPage)
+
+	// the cursor is kept between the calls of Next
+	ok, err = paginator.Next(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"c"}, names())
+	require.False(t, paginator.PageInfo().HasNextPage)
+
+	// the last page was read, nothing is requested anymore
+	ok, err = paginator.Next(context.Background())
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, []string{"", "2"}, cursors())
+
+	// a failed page can be requested again
+	c, cursors = newClient(t)
+	paginator = c.UsersPaginator(nil, client.WithHeader("X-Fail", ""))
+	_, err = paginator.Next(context.Background())
+	require.Error(t, err)
+	_, err = paginator.Next(context.Background())
+	require.Error(t, err)
+	require.Equal(t, []string{"", ""}, cursors())
+}
+
+func TestExecute(t *testing.T) {
+	t.Parallel()
+	c, cursors := newClient(t)
+	require.Equal(t, UsersQuery, OperationDocuments["Users"])
+
+	var res Users
+	require.NoError(t, c.Execute(context.Background(), "Users", map[string]interface{}{"after": "2"}, &res))
+	require.Len(t, res.Users.Edges, 1)
+	require.Equal(t, "c", res.Users.Edges[0].Node.Name)
+	require.Equal(t, []string{"2"}, cursors())
+
+	// the operations missing from OperationDocuments are not sent
+	err := c.Execute(context.Background(), "Unknown", nil, &res)
+	require.True(t, xerrors.Is(err, client.ErrUnknownOperation), err)
+	require.Equal(t, []string{"2"}, cursors())
+}
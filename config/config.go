@@ -1,16 +1,20 @@
 package config
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"unicode"
 
 	"github.com/99designs/gqlgen/codegen/config"
 	"github.com/perchcredit/gqlgenc/client"
+	"github.com/perchcredit/gqlgenc/graphqljson"
 	"github.com/perchcredit/gqlgenc/introspection"
 	"github.com/pkg/errors"
 	"github.com/vektah/gqlparser/v2"
@@ -72,6 +76,14 @@ func LoadConfigFromDefaultLocations() (*Config, error) {
 type EndPointConfig struct {
 	URL     string            `yaml:"url"`
 	Headers map[string]string `yaml:"headers,omitempty"`
+	// IntrospectionFile caches the introspection result as JSON, the endpoint is introspected
+	// only when the file does not exist or RefreshIntrospection is set
+	IntrospectionFile string `yaml:"introspectionFile,omitempty"`
+	// RefreshIntrospection introspects the endpoint even when IntrospectionFile exists
+	RefreshIntrospection bool `yaml:"-"`
+	// IntrospectionLevel is the introspection capability of the server, selecting the fields of the introspection
+	// query, introspection.LevelJune2018 supported by every server when empty
+	IntrospectionLevel introspection.Level `yaml:"introspectionLevel,omitempty"`
 }
 
 // findCfg searches for the config file in this directory and all parents up the tree
@@ -135,6 +147,20 @@ func LoadConfig(filename string) (*Config, error) {
 		return nil, errors.New("neither 'schema' nor 'endpoint' specified. Use schema to load from a local file, use endpoint to load from a remote server (using introspection)")
 	}
 
+	if cfg.Endpoint != nil {
+		if _, err := introspection.QueryForLevel(cfg.Endpoint.IntrospectionLevel); err != nil {
+			return nil, xerrors.Errorf("introspectionLevel: %w, use one of %v", err, introspection.Levels)
+		}
+	}
+
+	if cfg.Generate != nil && cfg.Generate.FieldNameCasing != "" && cfg.Generate.FieldNameCasing != SnakeCase {
+		return nil, xerrors.Errorf("unknown fieldNameCasing %q, use %q", cfg.Generate.FieldNameCasing, SnakeCase)
+	}
+
+	if cfg.Generate != nil && cfg.Generate.Receivers != "" && cfg.Generate.Receivers != PointerReceivers && cfg.Generate.Receivers != ValueReceivers {
+		return nil, xerrors.Errorf("unknown receivers %q, use %q or %q", cfg.Generate.Receivers, PointerReceivers, ValueReceivers)
+	}
+
 	// https://github.com/99designs/gqlgen/blob/3a31a752df764738b1f6e99408df3b169d514784/codegen/config/config.go#L120
 	for _, f := range cfg.SchemaFilename {
 		var matches []string
@@ -247,6 +273,38 @@ func (c *Config) LoadSchema(ctx context.Context) error {
 }
 
 func (c *Config) loadRemoteSchema(ctx context.Context) (*ast.Schema, error) {
+	data, err := c.introspectionData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var res introspection.Query
+	if err := graphqljson.UnmarshalData(data, &res); err != nil {
+		return nil, xerrors.Errorf("decode introspection result: %w", err)
+	}
+
+	schema, gqlErr := validator.ValidateSchemaDocument(introspection.ParseIntrospectionQuery(res))
+	if gqlErr != nil {
+		return nil, xerrors.Errorf("validation error: %w", gqlErr)
+	}
+
+	return schema, nil
+}
+
+// introspectionData returns the data of the introspection result, read from the introspection file
+// when it exists and else introspected from the endpoint then written to the introspection file
+func (c *Config) introspectionData(ctx context.Context) (json.RawMessage, error) {
+	filename := c.Endpoint.IntrospectionFile
+	if filename != "" && !c.Endpoint.RefreshIntrospection {
+		data, err := readIntrospectionFile(filename)
+		if err == nil {
+			return data, nil
+		}
+		if !xerrors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
 	addHeader := func(req *http.Request) {
 		for key, value := range c.Endpoint.Headers {
 			req.Header.Set(key, value)
@@ -254,22 +312,98 @@ func (c *Config) loadRemoteSchema(ctx context.Context) (*ast.Schema, error) {
 	}
 
 	gqlclient := client.NewClient(client.ClientOptions{
-		HTTPClient:         http.DefaultClient,
+		HTTPClient:         client.NewHTTPClient(0, client.RedirectNone),
 		BaseURL:            c.Endpoint.URL,
 		HTTPRequestOptions: []client.HTTPRequestOption{addHeader},
 	})
 
-	var res introspection.Query
-	if err := gqlclient.Post(ctx, "Query", introspection.Introspection, &res, nil); err != nil {
+	query, err := introspection.QueryForLevel(c.Endpoint.IntrospectionLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	var data json.RawMessage
+	if err := gqlclient.Post(ctx, "Query", query, &data, nil); err != nil {
+		if reason, ok := introspectionDisabled(err); ok {
+			return nil, c.introspectionDisabledError(reason)
+		}
+
 		return nil, xerrors.Errorf("introspection query failed: %w", err)
 	}
 
-	schema, err := validator.ValidateSchemaDocument(introspection.ParseIntrospectionQuery(res))
+	var introspected struct {
+		Schema json.RawMessage `json:"__schema"`
+	}
+	if err := json.Unmarshal(data, &introspected); err == nil && (len(introspected.Schema) == 0 || string(introspected.Schema) == "null") {
+		return nil, c.introspectionDisabledError("the introspection result has no __schema")
+	}
+
+	if filename != "" {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data, "", "  "); err != nil {
+			return nil, xerrors.Errorf("encode introspection result: %w", err)
+		}
+		if err := ioutil.WriteFile(filename, buf.Bytes(), 0o644); err != nil {
+			return nil, xerrors.Errorf("write introspection file: %w", err)
+		}
+	}
+
+	return data, nil
+}
+
+// ErrIntrospectionDisabled is returned when loading the schema from an endpoint which disables introspection
+var ErrIntrospectionDisabled = xerrors.New("introspection is disabled")
+
+// introspectionDisabledMessage matches the errors of the common servers refusing introspection queries
+var introspectionDisabledMessage = regexp.MustCompile(`(?i)introspection.*(disabled|not allowed|not enabled|forbidden)|(cannot query|unknown|undefined).*__schema|__schema.*(undefined|not defined)`)
+
+// introspectionDisabled returns the message of the error refusing the introspection query, ok is false
+// when the query failed for another reason
+func introspectionDisabled(err error) (message string, ok bool) {
+	var errResponse *client.ErrorResponse
+	if !xerrors.As(err, &errResponse) {
+		return "", false
+	}
+
+	if errResponse.GqlErrors != nil {
+		for _, gqlErr := range *errResponse.GqlErrors {
+			if introspectionDisabledMessage.MatchString(gqlErr.Message) {
+				return gqlErr.Message, true
+			}
+		}
+	}
+
+	if errResponse.NetworkError != nil && introspectionDisabledMessage.MatchString(errResponse.NetworkError.Message) {
+		return errResponse.NetworkError.Message, true
+	}
+
+	return "", false
+}
+
+func (c *Config) introspectionDisabledError(reason string) error {
+	return xerrors.Errorf("%s refused the introspection query (%s). Load the schema from its SDL files with `schema` instead of `endpoint`, "+
+		"or set endpoint.introspectionFile to an introspection result taken where introspection is enabled: %w",
+		c.Endpoint.URL, reason, ErrIntrospectionDisabled)
+}
+
+// readIntrospectionFile reads an introspection result, either the data object or the whole response holding it
+func readIntrospectionFile(filename string) (json.RawMessage, error) {
+	b, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return nil, xerrors.Errorf("validation error: %w", err)
+		return nil, xerrors.Errorf("read introspection file: %w", err)
 	}
 
-	return schema, nil
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(b, &response); err != nil {
+		return nil, xerrors.Errorf("decode introspection file %s: %w", filename, err)
+	}
+	if response.Data != nil {
+		return response.Data, nil
+	}
+
+	return b, nil
 }
 
 func (c *Config) loadLocalSchema() (*ast.Schema, error) {
@@ -284,6 +418,166 @@ func (c *Config) loadLocalSchema() (*ast.Schema, error) {
 type GenerateConfig struct {
 	Prefix *NamingConfig `yaml:"prefix,omitempty"`
 	Suffix *NamingConfig `yaml:"suffix,omitempty"`
+	// TimeLayout is the default layout used by the generated client to encode time variables
+	TimeLayout string `yaml:"timeLayout,omitempty"`
+	// Streaming generates a Stream method for operations selecting a single top-level list field
+	Streaming bool `yaml:"streaming,omitempty"`
+	// OptionalInputs generates the nullable fields of input types as client.Optional instead of pointers
+	OptionalInputs bool `yaml:"optionalInputs,omitempty"`
+	// ClientInterfaceName is the name of the generated interface implemented by the client, not generated when empty
+	ClientInterfaceName string `yaml:"clientInterfaceName,omitempty"`
+	// FakeClient generates FakeClient, an in-memory client returning canned values
+	FakeClient bool `yaml:"fakeClient,omitempty"`
+	// BackgroundMethods generates <Operation>BG methods calling the operation with context.Background()
+	BackgroundMethods bool `yaml:"backgroundMethods,omitempty"`
+	// Accessors generates getters on the response structs for the fields reached through nullable fields
+	Accessors bool `yaml:"accessors,omitempty"`
+	// OperationHashHeader is the header carrying the sha256 of the operation document, not sent when empty
+	OperationHashHeader string `yaml:"operationHashHeader,omitempty"`
+	// OperationNameHeader is the default ClientOptions.OperationNameHeader of the generated client, the header
+	// sending the operation name
+	OperationNameHeader string `yaml:"operationNameHeader,omitempty"`
+	// MinifyQueries sends minified operation documents, the formatted ones are kept in <Operation>QueryReadable
+	MinifyQueries bool `yaml:"minifyQueries,omitempty"`
+	// Pagination generates <Operation>All methods iterating the nodes of a Relay connection, requires Go 1.23
+	Pagination bool `yaml:"pagination,omitempty"`
+	// RawMethods generates <Operation>Raw methods also returning the undecoded data of the response
+	RawMethods bool `yaml:"rawMethods,omitempty"`
+	// DefaultVariables are the values, by operation then variable name, sent for the nullable variables left nil
+	DefaultVariables map[string]map[string]interface{} `yaml:"defaultVariables,omitempty"`
+	// FreeFunctions generates <Operation> package-level functions taking the client as first argument, alongside the
+	// methods, the response structs need a prefix or suffix so that their names differ
+	FreeFunctions bool `yaml:"freeFunctions,omitempty"`
+	// WatchMethods generates Watch<Operation> methods polling the operation and sending the changed responses on a channel
+	WatchMethods bool `yaml:"watchMethods,omitempty"`
+	// PrefetchMethods generates Prefetch<Query> methods storing the response of the queries in the cache of the client
+	// without returning it, to warm the cache
+	PrefetchMethods bool `yaml:"prefetchMethods,omitempty"`
+	// BatchMethods generates <Operation>Batch methods calling the operation concurrently for a list of variables
+	BatchMethods bool `yaml:"batchMethods,omitempty"`
+	// VariablesMethods generates <Operation>WithVariables methods taking the <Operation>Variables struct and
+	// <Operation>Map methods taking the variables as a map, for the operations with variables
+	VariablesMethods bool `yaml:"variablesMethods,omitempty"`
+	// ValidateVariables generates <Operation>Variables structs with a Validate method checking that the non-null
+	// variables of nillable types are set, the generated methods call it before sending the operation
+	ValidateVariables bool `yaml:"validateVariables,omitempty"`
+	// SchemaHash generates the SchemaHash constant, the client.SchemaHash of the schema the client is generated from
+	SchemaHash bool `yaml:"schemaHash,omitempty"`
+	// Gofumpt formats the generated files with gofumpt instead of gofmt
+	Gofumpt bool `yaml:"gofumpt,omitempty"`
+	// ConstraintDirective is the directive of the input field constraints checked by the generated Validate methods,
+	// constraint when empty
+	ConstraintDirective string `yaml:"constraintDirective,omitempty"`
+	// EnumValues are the integers sent and received instead of the names of the generated enums, by enum
+	// then value name, for servers expecting integer enums
+	EnumValues map[string]map[string]int `yaml:"enumValues,omitempty"`
+	// SchemaDefaults generates constants for the scalar and enum default values of the variables, arguments and input
+	// fields, sent for the nullable variables left nil and set by New<Operation>Variables and New<Input> constructors
+	SchemaDefaults bool `yaml:"schemaDefaults,omitempty"`
+	// InputBuilders generates an <Input>Builder per input type, setting the fields with chained calls and the
+	// nested inputs with their own builder, starting from New<Input> when SchemaDefaults sets defaults
+	InputBuilders bool `yaml:"inputBuilders,omitempty"`
+	// InputJSONSchema is the directory the JSON Schema documents of the input types are written to,
+	// <Input>.schema.json, not written when empty
+	InputJSONSchema string `yaml:"inputJSONSchema,omitempty"`
+	// ExecuteMethod generates OperationDocuments, the documents of the operations by name, and the Execute method
+	// sending one of them chosen at runtime
+	ExecuteMethod bool `yaml:"executeMethod,omitempty"`
+	// OperationRegistry generates OperationRegistry, the client.OperationTypes of the operations by name, and the
+	// <Operation>Variables structs of the operations with variables, for tooling
+	OperationRegistry bool `yaml:"operationRegistry,omitempty"`
+	// SourceComments comments the generated methods of each operation with the file:line it is declared at
+	SourceComments bool `yaml:"sourceComments,omitempty"`
+	// ErrorCodeEnum is the schema enum of the extensions.code of the graphql errors,
+	// generates <Enum>s and Has<Enum> reading them from an error when set
+	ErrorCodeEnum string `yaml:"errorCodeEnum,omitempty"`
+	// Complexity generates <Operation>Complexity constants estimating the cost of the operations when set
+	Complexity *ComplexityConfig `yaml:"complexity,omitempty"`
+	// FieldNameCasing is the casing of the response keys of the fields selected without alias,
+	// the schema field names when empty
+	FieldNameCasing string `yaml:"fieldNameCasing,omitempty"`
+	// FieldNames are the response keys by "Type.field", overriding FieldNameCasing
+	FieldNames map[string]string `yaml:"fieldNames,omitempty"`
+	// Receivers are the receivers of the generated accessors and Validate methods, PointerReceivers or
+	// ValueReceivers, each method keeping its usual receiver when empty. UnmarshalJSON keeps a pointer receiver
+	// and MarshalJSON a value receiver, which json.Marshal calls for values that are not addressable.
+	Receivers string `yaml:"receivers,omitempty"`
+}
+
+// Receivers of the generated methods
+const (
+	PointerReceivers = "pointer"
+	ValueReceivers   = "value"
+)
+
+// PointerReceiver reports whether a generated method whose usual receiver is a pointer when usual is set
+// has a pointer receiver
+func (c *GenerateConfig) PointerReceiver(usual bool) bool {
+	if c == nil {
+		return usual
+	}
+
+	switch c.Receivers {
+	case PointerReceivers:
+		return true
+	case ValueReceivers:
+		return false
+	}
+
+	return usual
+}
+
+// SnakeCase is the FieldNameCasing of the servers answering fooBar as foo_bar
+const SnakeCase = "snake_case"
+
+// ResponseKey returns the key the server answers the field of typeName selected without alias with
+func (c *GenerateConfig) ResponseKey(typeName, fieldName string) string {
+	if c == nil {
+		return fieldName
+	}
+
+	if key, ok := c.FieldNames[typeName+"."+fieldName]; ok {
+		return key
+	}
+
+	if c.FieldNameCasing == SnakeCase {
+		return snakeCase(fieldName)
+	}
+
+	return fieldName
+}
+
+// snakeCase converts a camelCase name, keeping initialisms together: userID becomes user_id
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// ComplexityConfig weighs the fields of the operations to estimate their cost, the sum of the weights of the
+// selected fields times the sizes of the lists holding them
+type ComplexityConfig struct {
+	// FieldWeight is the cost of a field, 1 when not set
+	FieldWeight int `yaml:"fieldWeight,omitempty"`
+	// ListSize is the number of elements expected from a list field, 10 when not set, the first or last
+	// argument of the field when it is a literal
+	ListSize int `yaml:"listSize,omitempty"`
+	// Weights are the costs of fields by "Type.field", overriding FieldWeight
+	Weights map[string]int `yaml:"weights,omitempty"`
+	// ListSizes are the numbers of elements expected from fields by "Type.field", overriding ListSize and
+	// the arguments of the fields
+	ListSizes map[string]int `yaml:"listSizes,omitempty"`
 }
 
 type NamingConfig struct {
@@ -1,10 +1,19 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"runtime"
 	"testing"
 
+	"github.com/99designs/gqlgen/codegen/config"
+	"github.com/perchcredit/gqlgenc/client"
+	"github.com/perchcredit/gqlgenc/introspection"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -71,5 +80,145 @@ func TestLoadConfig(t *testing.T) {
 		require.Equal(t, c.Generate.Suffix.Query, "Foo")
 		require.Equal(t, c.Generate.Prefix.Mutation, "Hoge")
 		require.Equal(t, c.Generate.Prefix.Query, "Data")
+		require.Equal(t, c.Generate.TimeLayout, "2006-01-02T15:04:05Z")
+		require.Equal(t, "user_id", c.Generate.ResponseKey("User", "id"))
+		require.Equal(t, "created_at", c.Generate.ResponseKey("User", "createdAt"))
+		require.Equal(t, "owner_id", c.Generate.ResponseKey("User", "ownerID"))
+		require.Equal(t, "http_status", c.Generate.ResponseKey("User", "HTTPStatus"))
+		require.Equal(t, "createdAt", (*GenerateConfig)(nil).ResponseKey("User", "createdAt"))
+		require.False(t, c.Generate.PointerReceiver(true))
+		require.True(t, (*GenerateConfig)(nil).PointerReceiver(true))
+		require.True(t, (&GenerateConfig{Receivers: PointerReceivers}).PointerReceiver(false))
+	})
+
+	t.Run("unknown receivers", func(t *testing.T) {
+		t.Parallel()
+		_, err := LoadConfig("testdata/cfg/receivers.yml")
+		require.EqualError(t, err, `unknown receivers "ptr", use "pointer" or "value"`)
+	})
+}
+
+func TestIntrospectionFile(t *testing.T) {
+	t.Parallel()
+	introspected := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		introspected++
+		_, _ = w.Write([]byte(`{"data":{"__schema":{"queryType":{"name":"Query"},"mutationType":{"name":"Mutation"},"types":[` +
+			`{"kind":"OBJECT","name":"Query","fields":[{"name":"hello","args":[],"type":{"kind":"SCALAR","name":"String"}}],"interfaces":[]},` +
+			`{"kind":"OBJECT","name":"Mutation","fields":[{"name":"bye","args":[],"type":{"kind":"SCALAR","name":"String"}}],"interfaces":[]},` +
+			`{"kind":"SCALAR","name":"String"}],"directives":[]}}}`))
+	}))
+	defer ts.Close()
+
+	filename := filepath.Join(t.TempDir(), "introspection.json")
+	cfg := &Config{
+		Endpoint:  &EndPointConfig{URL: ts.URL, IntrospectionFile: filename},
+		GQLConfig: &config.Config{},
+	}
+
+	require.NoError(t, cfg.LoadSchema(context.Background()))
+	require.Equal(t, 1, introspected)
+	require.NotNil(t, cfg.GQLConfig.Schema.Query.Fields.ForName("hello"))
+	require.FileExists(t, filename)
+
+	require.NoError(t, cfg.LoadSchema(context.Background()))
+	require.Equal(t, 1, introspected)
+	require.NotNil(t, cfg.GQLConfig.Schema.Query.Fields.ForName("hello"))
+
+	cfg.Endpoint.RefreshIntrospection = true
+	require.NoError(t, cfg.LoadSchema(context.Background()))
+	require.Equal(t, 2, introspected)
+}
+
+func TestIntrospectionLevel(t *testing.T) {
+	t.Parallel()
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req client.Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		queries = append(queries, req.Query)
+		_, _ = w.Write([]byte(`{"data":{"__schema":{"queryType":{"name":"Query"},"mutationType":{"name":"Mutation"},"types":[` +
+			`{"kind":"OBJECT","name":"Query","fields":[{"name":"hello","args":[{"name":"name","type":{"kind":"SCALAR","name":"String"},"isDeprecated":true,"deprecationReason":"Use lookup."},{"name":"lookup","type":{"kind":"INPUT_OBJECT","name":"Lookup"}}],"type":{"kind":"SCALAR","name":"String"}}],"interfaces":[]},` +
+			`{"kind":"OBJECT","name":"Mutation","fields":[{"name":"bye","args":[],"type":{"kind":"SCALAR","name":"String"}}],"interfaces":[]},` +
+			`{"kind":"INPUT_OBJECT","name":"Lookup","inputFields":[{"name":"id","type":{"kind":"SCALAR","name":"String"}},{"name":"key","type":{"kind":"SCALAR","name":"String"},"isDeprecated":true}],"isOneOf":true},` +
+			`{"kind":"SCALAR","name":"URL","specifiedByURL":"https://url.spec.whatwg.org"},` +
+			`{"kind":"SCALAR","name":"String"}],"directives":[` +
+			`{"name":"specifiedBy","locations":["SCALAR"],"args":[{"name":"url","type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"String"}}}]},` +
+			`{"name":"oneOf","locations":["INPUT_OBJECT"],"args":[]},` +
+			`{"name":"deprecated","locations":["FIELD_DEFINITION","ARGUMENT_DEFINITION","INPUT_FIELD_DEFINITION","ENUM_VALUE"],"args":[{"name":"reason","type":{"kind":"SCALAR","name":"String"}}]}]}}}`))
+	}))
+	defer ts.Close()
+
+	cfg := &Config{Endpoint: &EndPointConfig{URL: ts.URL}, GQLConfig: &config.Config{}}
+	require.NoError(t, cfg.LoadSchema(context.Background()))
+	require.Equal(t, introspection.Introspection, queries[0])
+	require.NotContains(t, queries[0], "isOneOf")
+
+	cfg.Endpoint.IntrospectionLevel = introspection.LevelDraft
+	require.NoError(t, cfg.LoadSchema(context.Background()))
+	require.Contains(t, queries[1], "specifiedByURL")
+	require.Contains(t, queries[1], "inputFields(includeDeprecated: true)")
+	require.Contains(t, queries[1], "args(includeDeprecated: true)")
+	require.Contains(t, queries[1], "isOneOf")
+	require.True(t, introspection.IsIntrospection(queries[1]))
+	require.NotNil(t, cfg.GQLConfig.Schema.Types["Lookup"].Directives.ForName("oneOf"))
+	specifiedBy := cfg.GQLConfig.Schema.Types["URL"].Directives.ForName("specifiedBy")
+	require.NotNil(t, specifiedBy)
+	require.Equal(t, "https://url.spec.whatwg.org", specifiedBy.Arguments.ForName("url").Value.Raw)
+	deprecated := cfg.GQLConfig.Schema.Query.Fields.ForName("hello").Arguments.ForName("name").Directives.ForName("deprecated")
+	require.NotNil(t, deprecated)
+	require.Equal(t, "Use lookup.", deprecated.Arguments.ForName("reason").Value.Raw)
+	require.Nil(t, cfg.GQLConfig.Schema.Query.Fields.ForName("hello").Arguments.ForName("lookup").Directives.ForName("deprecated"))
+	deprecated = cfg.GQLConfig.Schema.Types["Lookup"].Fields.ForName("key").Directives.ForName("deprecated")
+	require.NotNil(t, deprecated)
+	require.Nil(t, deprecated.Arguments.ForName("reason"))
+	require.Nil(t, cfg.GQLConfig.Schema.Types["Lookup"].Fields.ForName("id").Directives.ForName("deprecated"))
+
+	cfg.Endpoint.IntrospectionLevel = "2015"
+	err := cfg.LoadSchema(context.Background())
+	require.True(t, xerrors.Is(err, introspection.ErrUnknownLevel), err)
+	require.Len(t, queries, 2)
+}
+
+func TestIntrospectionDisabled(t *testing.T) {
+	t.Parallel()
+	for name, response := range map[string]struct {
+		code int
+		body string
+	}{
+		"gqlgen":           {http.StatusUnprocessableEntity, `{"errors":[{"message":"introspection disabled"}],"data":null}`},
+		"apollo":           {http.StatusBadRequest, `{"errors":[{"message":"GraphQL introspection is not allowed by Apollo Server, but the query contained __schema or __type."}]}`},
+		"validation":       {http.StatusOK, `{"errors":[{"message":"Cannot query field \"__schema\" on type \"Query\"."}]}`},
+		"null __schema":    {http.StatusOK, `{"data":{"__schema":null}}`},
+		"missing __schema": {http.StatusOK, `{"data":{}}`},
+	} {
+		response := response
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(response.code)
+				_, _ = w.Write([]byte(response.body))
+			}))
+			defer ts.Close()
+
+			cfg := &Config{Endpoint: &EndPointConfig{URL: ts.URL}, GQLConfig: &config.Config{}}
+			err := cfg.LoadSchema(context.Background())
+			require.True(t, xerrors.Is(err, ErrIntrospectionDisabled), err)
+			require.Contains(t, err.Error(), "`schema`")
+		})
+	}
+
+	t.Run("other errors", func(t *testing.T) {
+		t.Parallel()
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"missing token"}]}`))
+		}))
+		defer ts.Close()
+
+		cfg := &Config{Endpoint: &EndPointConfig{URL: ts.URL}, GQLConfig: &config.Config{}}
+		err := cfg.LoadSchema(context.Background())
+		require.Error(t, err)
+		require.False(t, xerrors.Is(err, ErrIntrospectionDisabled))
 	})
 }
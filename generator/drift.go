@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/99designs/gqlgen/api"
+	"github.com/perchcredit/gqlgenc/config"
+	"golang.org/x/xerrors"
+)
+
+// CheckSchemaDrift generates the code against the current schema, introspecting the endpoint when configured,
+// and reports whether it differs from the generated files on disk. The files on disk are restored afterwards
+// so the check never regenerates the client. An error is returned when the queries no longer validate.
+func CheckSchemaDrift(ctx context.Context, cfg *config.Config, option ...api.Option) (bool, error) {
+	return checkSchemaDrift(cfg, func() error {
+		return Generate(ctx, cfg, option...)
+	})
+}
+
+// checkSchemaDrift is CheckSchemaDrift generating the code with generate
+func checkSchemaDrift(cfg *config.Config, generate func() error) (drift bool, err error) {
+	filenames, err := generatedFilenames(cfg)
+	if err != nil {
+		return false, err
+	}
+
+	committed := make(map[string][]byte, len(filenames))
+	for _, filename := range filenames {
+		content, err := ioutil.ReadFile(filename)
+		if err != nil && !os.IsNotExist(err) {
+			return false, xerrors.Errorf("failed to read %s: %w", filename, err)
+		}
+
+		committed[filename] = content
+	}
+
+	// the JSON Schema documents of the input types missing from the disk are only known once the schema is loaded,
+	// they did not exist before generating
+	addGenerated := func() error {
+		filenames, err := generatedFilenames(cfg)
+		if err != nil {
+			return err
+		}
+		for _, filename := range filenames {
+			if _, ok := committed[filename]; !ok {
+				committed[filename] = nil
+			}
+		}
+
+		return nil
+	}
+
+	defer func() {
+		restoreErr := addGenerated()
+		if restoreErr == nil {
+			restoreErr = restoreFiles(committed)
+		}
+		if restoreErr != nil && err == nil {
+			err = restoreErr
+		}
+	}()
+
+	if err := generate(); err != nil {
+		return false, err
+	}
+
+	if err := addGenerated(); err != nil {
+		return false, err
+	}
+
+	for filename, content := range committed {
+		generated, err := ioutil.ReadFile(filename)
+		if err != nil && !os.IsNotExist(err) {
+			return false, xerrors.Errorf("failed to read %s: %w", filename, err)
+		}
+
+		if !bytes.Equal(content, generated) {
+			drift = true
+		}
+	}
+
+	return drift, nil
+}
+
+// restoreFiles writes back the given contents, removing the files which did not exist
+func restoreFiles(contents map[string][]byte) error {
+	for filename, content := range contents {
+		if content == nil {
+			if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+				return xerrors.Errorf("failed to remove %s: %w", filename, err)
+			}
+
+			continue
+		}
+
+		if err := ioutil.WriteFile(filename, content, 0o644); err != nil {
+			return xerrors.Errorf("failed to restore %s: %w", filename, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gqlgenconfig "github.com/99designs/gqlgen/codegen/config"
+	"github.com/perchcredit/gqlgenc/config"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestCheckSchemaDrift(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Model:     gqlgenconfig.PackageConfig{Filename: filepath.Join(dir, "gen", "models_gen.go"), Package: "gen"},
+		Client:    gqlgenconfig.PackageConfig{Filename: filepath.Join(dir, "gen", "client.go"), Package: "gen"},
+		GQLConfig: &gqlgenconfig.Config{},
+	}
+	generated := generatedGoFilenames(cfg)
+	require.Contains(t, generated, filepath.Join(dir, "gen", "models_gen_defaults.go"))
+	require.Contains(t, generated, filepath.Join(dir, "gen", "models_gen_enums.go"))
+	require.Contains(t, generated, filepath.Join(dir, "gen", "models_gen_builders.go"))
+
+	// generate stands for Generate, writing every generated file
+	generate := func() error {
+		for _, filename := range generated {
+			if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(filename, []byte("package gen\n"), 0o644); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+	check := func() bool {
+		drift, err := checkSchemaDrift(cfg, generate)
+		require.NoError(t, err)
+
+		return drift
+	}
+
+	require.NoError(t, generate())
+	require.False(t, check())
+
+	// stale files are reported and left as they are
+	for _, filename := range generated {
+		content, err := ioutil.ReadFile(filename)
+		require.NoError(t, err)
+		stale := append(content, []byte("// stale\n")...)
+		require.NoError(t, ioutil.WriteFile(filename, stale, 0o644))
+
+		require.True(t, check(), filename)
+		restored, err := ioutil.ReadFile(filename)
+		require.NoError(t, err)
+		require.Equal(t, string(stale), string(restored))
+
+		require.NoError(t, ioutil.WriteFile(filename, content, 0o644))
+		require.False(t, check(), filename)
+	}
+
+	// the files missing from the disk are reported and removed
+	require.NoError(t, os.Remove(validateFilename(cfg)))
+	require.True(t, check())
+	require.NoFileExists(t, validateFilename(cfg))
+}
+
+func TestCheckSchemaDriftInputJSONSchemas(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Client:    gqlgenconfig.PackageConfig{Filename: filepath.Join(dir, "gen", "client.go"), Package: "gen"},
+		Generate:  &config.GenerateConfig{InputJSONSchema: filepath.Join(dir, "schemas")},
+		GQLConfig: &gqlgenconfig.Config{},
+	}
+	schema := `
+		input UserInput { name: String! }
+		type Query { user(input: UserInput): String }
+	`
+	filename := filepath.Join(dir, "schemas", "UserInput.schema.json")
+
+	// generate stands for Generate, loading the schema then writing the JSON Schema documents
+	generate := func() error {
+		cfg.GQLConfig.Schema = gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: schema})
+
+		return writeInputJSONSchemas(cfg)
+	}
+	check := func() bool {
+		cfg.GQLConfig.Schema = nil
+		drift, err := checkSchemaDrift(cfg, generate)
+		require.NoError(t, err)
+
+		return drift
+	}
+
+	require.NoError(t, generate())
+	filenames, err := generatedFilenames(cfg)
+	require.NoError(t, err)
+	require.Contains(t, filenames, filename)
+	require.False(t, check())
+
+	// stale documents are reported and left as they are
+	content, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	stale := append(content, []byte("// stale\n")...)
+	require.NoError(t, ioutil.WriteFile(filename, stale, 0o644))
+	require.True(t, check())
+	restored, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, string(stale), string(restored))
+	require.NoError(t, ioutil.WriteFile(filename, content, 0o644))
+
+	// the documents of the new input types are reported and removed
+	schema = `
+		input UserInput { name: String! }
+		input TeamInput { name: String! }
+		type Query { user(input: UserInput): String team(input: TeamInput): String }
+	`
+	require.True(t, check())
+	require.NoFileExists(t, filepath.Join(dir, "schemas", "TeamInput.schema.json"))
+	require.FileExists(t, filename)
+}
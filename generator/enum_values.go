@@ -0,0 +1,187 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/99designs/gqlgen/plugin/modelgen"
+	"github.com/perchcredit/gqlgenc/config"
+	"golang.org/x/xerrors"
+)
+
+// wireEnum is a generated enum sent and received as integers
+type wireEnum struct {
+	Name   string
+	Values []*wireEnumValue
+}
+
+// wireEnumValue is the integer of the enum value Const
+type wireEnumValue struct {
+	Const string
+	Wire  int
+}
+
+// enumWireValues collects the enums of GenerateConfig.EnumValues from the models built by modelgen
+type enumWireValues struct {
+	cfg   *config.Config
+	built bool
+	enums []*wireEnum
+	err   error
+}
+
+// hook records the enums to send as integers then calls next
+func (e *enumWireValues) hook(next modelgen.BuildMutateHook) modelgen.BuildMutateHook {
+	return func(b *modelgen.ModelBuild) *modelgen.ModelBuild {
+		if next != nil {
+			b = next(b)
+		}
+
+		e.built = true
+		e.enums = nil
+		e.err = nil
+		if e.cfg.Generate == nil || len(e.cfg.Generate.EnumValues) == 0 {
+			return b
+		}
+
+		generated := make(map[string]bool, len(b.Enums))
+		for _, enum := range b.Enums {
+			generated[enum.Name] = true
+
+			values, ok := e.cfg.Generate.EnumValues[enum.Name]
+			if !ok {
+				continue
+			}
+
+			wire := &wireEnum{Name: templates.ToGo(enum.Name)}
+			seen := make(map[int]string, len(values))
+			for _, value := range enum.Values {
+				n, ok := values[value.Name]
+				if !ok {
+					e.err = xerrors.Errorf("enumValues.%s: missing the integer of %s", enum.Name, value.Name)
+
+					return b
+				}
+				if other, ok := seen[n]; ok {
+					e.err = xerrors.Errorf("enumValues.%s: %s and %s are both %d", enum.Name, other, value.Name, n)
+
+					return b
+				}
+				seen[n] = value.Name
+
+				wire.Values = append(wire.Values, &wireEnumValue{
+					Const: templates.ToGo(enum.Name) + templates.ToGo(value.Name),
+					Wire:  n,
+				})
+			}
+			if len(values) != len(enum.Values) {
+				e.err = xerrors.Errorf("enumValues.%s: unknown values, %s has %d values", enum.Name, enum.Name, len(enum.Values))
+
+				return b
+			}
+
+			e.enums = append(e.enums, wire)
+		}
+
+		names := make([]string, 0, len(e.cfg.Generate.EnumValues))
+		for name := range e.cfg.Generate.EnumValues {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !generated[name] {
+				e.err = xerrors.Errorf("enumValues.%s: not an enum generated in the models", name)
+
+				return b
+			}
+		}
+
+		return b
+	}
+}
+
+func (e *enumWireValues) filename() string {
+	return enumValuesFilename(e.cfg)
+}
+
+// enumValuesFilename is the file holding the JSON encoding of the enums sent as integers, next to the models
+func enumValuesFilename(cfg *config.Config) string {
+	return strings.TrimSuffix(cfg.Model.Filename, ".go") + "_enums.go"
+}
+
+// render writes the JSON encoding of the enums sent as integers, removing the file when there is none
+func (e *enumWireValues) render() error {
+	if !e.built {
+		return nil
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+
+	if len(e.enums) == 0 {
+		if err := os.Remove(e.filename()); err != nil && !os.IsNotExist(err) {
+			return xerrors.Errorf("remove %s: %w", filepath.Base(e.filename()), err)
+		}
+
+		return nil
+	}
+
+	if err := templates.Render(templates.Options{
+		PackageName: e.cfg.Model.Package,
+		Filename:    e.filename(),
+		Template:    enumValuesTemplate,
+		Data:        e.enums,
+		Packages:    e.cfg.GQLConfig.Packages,
+		PackageDoc:  "// Code generated by github.com/perchcredit/gqlgenc, DO NOT EDIT.\n",
+	}); err != nil {
+		return xerrors.Errorf("%s generating failed: %w", e.filename(), err)
+	}
+
+	return nil
+}
+
+const enumValuesTemplate = `{{ reserveImport "encoding/json" }}
+{{ reserveImport "fmt" }}
+
+{{- range $enum := . }}
+
+// MarshalJSON marshals {{ $enum.Name }} as the integer the server expects
+func (e {{ $enum.Name }}) MarshalJSON() ([]byte, error) {
+	switch e {
+	{{- range $value := $enum.Values }}
+	case {{ $value.Const }}:
+		return []byte("{{ $value.Wire }}"), nil
+	{{- end }}
+	}
+
+	return nil, fmt.Errorf("%s is not a valid {{ $enum.Name }}", string(e))
+}
+
+// UnmarshalJSON unmarshals {{ $enum.Name }} from its integer, or from its name
+func (e *{{ $enum.Name }}) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		return e.UnmarshalGQL(name)
+	}
+
+	var wire int
+	if err := json.Unmarshal(b, &wire); err != nil {
+		return fmt.Errorf("{{ $enum.Name }} must be an integer or a string: %w", err)
+	}
+
+	switch wire {
+	{{- range $value := $enum.Values }}
+	case {{ $value.Wire }}:
+		*e = {{ $value.Const }}
+	{{- end }}
+	default:
+		return fmt.Errorf("%d is not a valid {{ $enum.Name }}", wire)
+	}
+
+	return nil
+}
+{{- end }}
+`
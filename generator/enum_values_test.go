@@ -0,0 +1,32 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/99designs/gqlgen/plugin/modelgen"
+	"github.com/perchcredit/gqlgenc/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnumWireValues(t *testing.T) {
+	t.Parallel()
+	build := func(enumValues map[string]map[string]int) *enumWireValues {
+		e := &enumWireValues{cfg: &config.Config{Generate: &config.GenerateConfig{EnumValues: enumValues}}}
+		e.hook(nil)(&modelgen.ModelBuild{Enums: []*modelgen.Enum{{
+			Name:   "Priority",
+			Values: []*modelgen.EnumValue{{Name: "LOW"}, {Name: "HIGH"}},
+		}}})
+
+		return e
+	}
+
+	e := build(map[string]map[string]int{"Priority": {"LOW": 0, "HIGH": 10}})
+	require.NoError(t, e.err)
+	require.Equal(t, []*wireEnum{{Name: "Priority", Values: []*wireEnumValue{{Const: "PriorityLow", Wire: 0}, {Const: "PriorityHigh", Wire: 10}}}}, e.enums)
+
+	require.EqualError(t, build(map[string]map[string]int{"Priority": {"LOW": 0}}).err, "enumValues.Priority: missing the integer of HIGH")
+	require.EqualError(t, build(map[string]map[string]int{"Priority": {"LOW": 1, "HIGH": 1}}).err, "enumValues.Priority: LOW and HIGH are both 1")
+	require.EqualError(t, build(map[string]map[string]int{"Priority": {"LOW": 0, "HIGH": 1, "URGENT": 2}}).err, "enumValues.Priority: unknown values, Priority has 2 values")
+	require.EqualError(t, build(map[string]map[string]int{"Status": {"OPEN": 0}}).err, "enumValues.Status: not an enum generated in the models")
+	require.Empty(t, build(nil).enums)
+}
@@ -12,8 +12,26 @@ import (
 
 func Generate(ctx context.Context, cfg *config.Config, option ...api.Option) error {
 	var plugins []plugin.Plugin
+	var optionals *optionalInputs
+	var validators *inputValidators
+	var enums *enumWireValues
+	var defaults *inputDefaults
+	var builders *inputBuilders
 	if cfg.Model.IsDefined() {
-		plugins = append(plugins, modelgen.New())
+		modelgenPlugin := modelgen.New()
+		if cfg.Generate != nil && cfg.Generate.OptionalInputs {
+			optionals = &optionalInputs{cfg: cfg}
+			modelgenPlugin.(*modelgen.Plugin).MutateHook = optionals.hook()
+		}
+		validators = &inputValidators{cfg: cfg}
+		modelgenPlugin.(*modelgen.Plugin).MutateHook = validators.hook(modelgenPlugin.(*modelgen.Plugin).MutateHook)
+		enums = &enumWireValues{cfg: cfg}
+		modelgenPlugin.(*modelgen.Plugin).MutateHook = enums.hook(modelgenPlugin.(*modelgen.Plugin).MutateHook)
+		defaults = &inputDefaults{cfg: cfg}
+		modelgenPlugin.(*modelgen.Plugin).MutateHook = defaults.hook(modelgenPlugin.(*modelgen.Plugin).MutateHook)
+		builders = &inputBuilders{cfg: cfg, defaults: defaults}
+		modelgenPlugin.(*modelgen.Plugin).MutateHook = builders.hook(modelgenPlugin.(*modelgen.Plugin).MutateHook)
+		plugins = append(plugins, modelgenPlugin)
 	}
 	for _, o := range option {
 		o(cfg.GQLConfig, &plugins)
@@ -36,5 +54,72 @@ func Generate(ctx context.Context, cfg *config.Config, option ...api.Option) err
 		}
 	}
 
+	if optionals != nil && optionals.err != nil {
+		return xerrors.Errorf("generating optional inputs failed: %w\n", optionals.err)
+	}
+
+	if validators != nil {
+		if err := validators.render(); err != nil {
+			return xerrors.Errorf("generating input validation failed: %w\n", err)
+		}
+	}
+
+	if enums != nil {
+		if err := enums.render(); err != nil {
+			return xerrors.Errorf("generating enum values failed: %w\n", err)
+		}
+	}
+
+	if defaults != nil {
+		if err := defaults.render(); err != nil {
+			return xerrors.Errorf("generating input defaults failed: %w\n", err)
+		}
+	}
+
+	if builders != nil {
+		if err := builders.render(); err != nil {
+			return xerrors.Errorf("generating input builders failed: %w\n", err)
+		}
+	}
+
+	if err := writeInputJSONSchemas(cfg); err != nil {
+		return xerrors.Errorf("generating input json schemas failed: %w\n", err)
+	}
+
+	if cfg.Generate != nil && cfg.Generate.Gofumpt {
+		if err := gofumpt(generatedGoFilenames(cfg)); err != nil {
+			return xerrors.Errorf("formatting failed: %w\n", err)
+		}
+	}
+
+	operations := clientOperations(plugins)
+	for _, p := range plugins {
+		if hook, ok := p.(OperationsHook); ok {
+			if err := hook.GenerateOperations(cfg.GQLConfig, operations); err != nil {
+				return xerrors.Errorf("%s failed: %w\n", p.Name(), err)
+			}
+		}
+	}
+
 	return nil
 }
+
+// generatedGoFilenames are the Go files written by Generate, some of them only when their option is set
+func generatedGoFilenames(cfg *config.Config) []string {
+	filenames := []string{cfg.Client.Filename}
+	if cfg.Model.IsDefined() {
+		filenames = append(filenames, cfg.Model.Filename, validateFilename(cfg), enumValuesFilename(cfg), inputDefaultsFilename(cfg), inputBuildersFilename(cfg))
+	}
+
+	return filenames
+}
+
+// generatedFilenames are the files written by Generate, the Go files and the JSON Schema documents of the input types
+func generatedFilenames(cfg *config.Config) ([]string, error) {
+	schemas, err := inputJSONSchemaFilenames(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(generatedGoFilenames(cfg), schemas...), nil
+}
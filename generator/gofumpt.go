@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/xerrors"
+	"mvdan.cc/gofumpt/format"
+)
+
+// gofumpt formats the generated files with gofumpt, using the Go version and module path of their go.mod
+func gofumpt(filenames []string) error {
+	for _, filename := range filenames {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return xerrors.Errorf("read %s: %w", filename, err)
+		}
+
+		options, err := gofumptOptions(filepath.Dir(filename))
+		if err != nil {
+			return xerrors.Errorf("%s: %w", filename, err)
+		}
+
+		formatted, err := format.Source(src, options)
+		if err != nil {
+			return xerrors.Errorf("gofumpt %s: %w", filename, err)
+		}
+
+		if err := ioutil.WriteFile(filename, formatted, 0o644); err != nil {
+			return xerrors.Errorf("write %s: %w", filename, err)
+		}
+	}
+
+	return nil
+}
+
+// gofumptOptions reads the Go version and module path of the go.mod closest to dir,
+// formatting without them outside a module
+func gofumptOptions(dir string) (format.Options, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return format.Options{}, xerrors.Errorf("locate go.mod: %w", err)
+	}
+
+	for {
+		filename := filepath.Join(dir, "go.mod")
+		content, err := ioutil.ReadFile(filename)
+		if err == nil {
+			file, err := modfile.ParseLax(filename, content, nil)
+			if err != nil {
+				return format.Options{}, xerrors.Errorf("parse %s: %w", filename, err)
+			}
+
+			var options format.Options
+			if file.Module != nil {
+				options.ModulePath = file.Module.Mod.Path
+			}
+			if file.Go != nil {
+				options.LangVersion = file.Go.Version
+			}
+
+			return options, nil
+		}
+
+		if !os.IsNotExist(err) {
+			return format.Options{}, xerrors.Errorf("read %s: %w", filename, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return format.Options{}, nil
+		}
+		dir = parent
+	}
+}
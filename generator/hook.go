@@ -0,0 +1,26 @@
+package generator
+
+import (
+	"github.com/99designs/gqlgen/codegen/config"
+	"github.com/99designs/gqlgen/plugin"
+	"github.com/perchcredit/gqlgenc/clientgen"
+)
+
+// OperationsHook is implemented by the plugins post-processing the operations of the generated client,
+// to generate extra files for instance. Add them to Generate with api.AddPlugin, they are called
+// once every plugin mutated the config.
+type OperationsHook interface {
+	plugin.Plugin
+	GenerateOperations(cfg *config.Config, operations []*clientgen.Operation) error
+}
+
+// clientOperations returns the operations of the client generated by the clientgen plugin, if any
+func clientOperations(plugins []plugin.Plugin) []*clientgen.Operation {
+	for _, p := range plugins {
+		if clientPlugin, ok := p.(*clientgen.Plugin); ok {
+			return clientPlugin.Operations
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,250 @@
+package generator
+
+import (
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/99designs/gqlgen/plugin/modelgen"
+	"github.com/perchcredit/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/xerrors"
+)
+
+// builtInput is a generated input type with a fluent builder
+type builtInput struct {
+	Name string
+	// Defaults is set when the builder starts from the New<Input> holding the default values of the schema
+	Defaults bool
+	Setters  []*builderSetter
+}
+
+// builderSetter is a chained method of a builder setting a field
+type builderSetter struct {
+	Method string
+	Field  string
+	// Type is the value the setter takes, the type of the field without pointer or client.Optional
+	Type types.Type
+	// Wrap is how the field holds Type: "pointer", "optional" or empty for the value itself
+	Wrap string
+	// Nested is the input built by the builder the setter takes, appended to the field when List
+	Nested      string
+	List        bool
+	ElemPointer bool
+}
+
+// inputBuilders collects the generated input types to build from the models built by modelgen
+type inputBuilders struct {
+	cfg      *config.Config
+	defaults *inputDefaults
+	built    bool
+	inputs   []*builtInput
+}
+
+// hook records the input types then calls next
+func (i *inputBuilders) hook(next modelgen.BuildMutateHook) modelgen.BuildMutateHook {
+	return func(b *modelgen.ModelBuild) *modelgen.ModelBuild {
+		if next != nil {
+			b = next(b)
+		}
+
+		i.built = true
+		i.inputs = nil
+		if i.cfg.Generate == nil || !i.cfg.Generate.InputBuilders {
+			return b
+		}
+
+		generated := make(map[string]bool)
+		for _, model := range b.Models {
+			if definition := i.cfg.GQLConfig.Schema.Types[model.Name]; definition != nil && definition.Kind == ast.InputObject {
+				generated[templates.ToGo(model.Name)] = true
+			}
+		}
+
+		for _, model := range b.Models {
+			if !generated[templates.ToGo(model.Name)] {
+				continue
+			}
+
+			input := &builtInput{Name: templates.ToGo(model.Name)}
+			for _, field := range model.Fields {
+				input.Setters = append(input.Setters, i.setters(field, generated)...)
+			}
+			i.inputs = append(i.inputs, input)
+		}
+
+		return b
+	}
+}
+
+// setters returns the setter of the field, and the builder setter of the nested inputs it holds
+func (i *inputBuilders) setters(field *modelgen.Field, generated map[string]bool) []*builderSetter {
+	name := templates.ToGo(field.Name)
+	typ, wrap := field.Type, ""
+	switch t := typ.(type) {
+	case *types.Pointer:
+		typ, wrap = t.Elem(), "pointer"
+	case *types.Named:
+		if isOptional(t) {
+			typ, wrap = t.TypeArgs().At(0), "optional"
+		}
+	}
+
+	elem, list, elemPointer := typ, false, false
+	if slice, ok := elem.(*types.Slice); ok {
+		elem, list = slice.Elem(), true
+	}
+	if ptr, ok := elem.(*types.Pointer); ok {
+		elem, elemPointer = ptr.Elem(), true
+	}
+
+	method := name
+	if method == "Build" {
+		method = "SetBuild"
+	}
+
+	nested := ""
+	if named, ok := elem.(*types.Named); ok && generated[named.Obj().Name()] &&
+		named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == i.cfg.Model.ImportPath() {
+		nested = named.Obj().Name()
+	}
+
+	switch {
+	case nested == "":
+		return []*builderSetter{{Method: method, Field: name, Type: typ, Wrap: wrap}}
+	case list:
+		return []*builderSetter{
+			{Method: method, Field: name, Type: typ, Wrap: wrap},
+			{Method: "Add" + name, Field: name, Wrap: wrap, Nested: nested, List: true, ElemPointer: elemPointer},
+		}
+	}
+
+	return []*builderSetter{{Method: method, Field: name, Wrap: wrap, Nested: nested}}
+}
+
+func (i *inputBuilders) filename() string {
+	return inputBuildersFilename(i.cfg)
+}
+
+// inputBuildersFilename is the file holding the builders of the input types, next to the models
+func inputBuildersFilename(cfg *config.Config) string {
+	return strings.TrimSuffix(cfg.Model.Filename, ".go") + "_builders.go"
+}
+
+// render writes the builders of the inputs, removing the file when there is none
+func (i *inputBuilders) render() error {
+	if !i.built {
+		return nil
+	}
+
+	if len(i.inputs) == 0 {
+		if err := os.Remove(i.filename()); err != nil && !os.IsNotExist(err) {
+			return xerrors.Errorf("remove %s: %w", filepath.Base(i.filename()), err)
+		}
+
+		return nil
+	}
+
+	if i.defaults != nil {
+		defaulted := make(map[string]bool, len(i.defaults.inputs))
+		for _, input := range i.defaults.inputs {
+			defaulted[input.Name] = true
+		}
+		for _, input := range i.inputs {
+			input.Defaults = defaulted[input.Name]
+		}
+	}
+
+	if err := templates.Render(templates.Options{
+		PackageName: i.cfg.Model.Package,
+		Filename:    i.filename(),
+		Template:    inputBuildersTemplate,
+		Data:        i.inputs,
+		Packages:    i.cfg.GQLConfig.Packages,
+		PackageDoc:  "// Code generated by github.com/perchcredit/gqlgenc, DO NOT EDIT.\n",
+	}); err != nil {
+		return xerrors.Errorf("%s generating failed: %w", i.filename(), err)
+	}
+
+	return nil
+}
+
+const inputBuildersTemplate = `{{ reserveImport "github.com/perchcredit/gqlgenc/client" }}
+
+{{- range $input := . }}
+
+// {{ $input.Name }}Builder builds a {{ $input.Name }} with chained calls, the nested inputs with their own builder
+type {{ $input.Name }}Builder struct {
+	input {{ $input.Name }}
+}
+
+// New{{ $input.Name }}Builder returns a builder of {{ $input.Name }}
+{{- if $input.Defaults }} starting from the default values of the schema{{ end }}
+func New{{ $input.Name }}Builder() *{{ $input.Name }}Builder {
+	{{- if $input.Defaults }}
+	return &{{ $input.Name }}Builder{input: *New{{ $input.Name }}()}
+	{{- else }}
+	return &{{ $input.Name }}Builder{}
+	{{- end }}
+}
+{{- range $setter := $input.Setters }}
+{{- if $setter.Nested }}
+{{- if $setter.List }}
+
+// {{ $setter.Method }} appends to {{ $setter.Field }} the {{ $setter.Nested }} built by build
+func (b *{{ $input.Name }}Builder) {{ $setter.Method }}(build func(*{{ $setter.Nested }}Builder)) *{{ $input.Name }}Builder {
+	nested := New{{ $setter.Nested }}Builder()
+	build(nested)
+	{{- if eq $setter.Wrap "optional" }}
+	list := b.input.{{ $setter.Field }}.Value()
+	b.input.{{ $setter.Field }} = client.OptionalOf(append(list, {{ if not $setter.ElemPointer }}*{{ end }}nested.Build()))
+	{{- else }}
+	b.input.{{ $setter.Field }} = append(b.input.{{ $setter.Field }}, {{ if not $setter.ElemPointer }}*{{ end }}nested.Build())
+	{{- end }}
+
+	return b
+}
+{{- else }}
+
+// {{ $setter.Method }} sets {{ $setter.Field }} to the {{ $setter.Nested }} built by build
+func (b *{{ $input.Name }}Builder) {{ $setter.Method }}(build func(*{{ $setter.Nested }}Builder)) *{{ $input.Name }}Builder {
+	nested := New{{ $setter.Nested }}Builder()
+	build(nested)
+	{{- if eq $setter.Wrap "optional" }}
+	b.input.{{ $setter.Field }} = client.OptionalOf(*nested.Build())
+	{{- else if eq $setter.Wrap "pointer" }}
+	b.input.{{ $setter.Field }} = nested.Build()
+	{{- else }}
+	b.input.{{ $setter.Field }} = *nested.Build()
+	{{- end }}
+
+	return b
+}
+{{- end }}
+{{- else }}
+
+// {{ $setter.Method }} sets {{ $setter.Field }}
+func (b *{{ $input.Name }}Builder) {{ $setter.Method }}(value {{ $setter.Type | ref }}) *{{ $input.Name }}Builder {
+	{{- if eq $setter.Wrap "optional" }}
+	b.input.{{ $setter.Field }} = client.OptionalOf(value)
+	{{- else if eq $setter.Wrap "pointer" }}
+	b.input.{{ $setter.Field }} = &value
+	{{- else }}
+	b.input.{{ $setter.Field }} = value
+	{{- end }}
+
+	return b
+}
+{{- end }}
+{{- end }}
+
+// Build returns the {{ $input.Name }} built
+func (b *{{ $input.Name }}Builder) Build() *{{ $input.Name }} {
+	input := b.input
+
+	return &input
+}
+{{- end }}
+`
@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"go/types"
+	"testing"
+
+	gqlgenconfig "github.com/99designs/gqlgen/codegen/config"
+	"github.com/99designs/gqlgen/plugin/modelgen"
+	"github.com/perchcredit/gqlgenc/config"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestInputBuilders(t *testing.T) {
+	t.Parallel()
+	schema := gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: `
+		input ProfileInput { bio: String }
+		input CreateUserInput {
+			name: String!
+			profile: ProfileInput
+			main: ProfileInput!
+			others: [ProfileInput!]
+			build: Int
+		}
+		type Query { createUser(input: CreateUserInput!): String }
+	`})
+	cfg := &config.Config{
+		Model:     gqlgenconfig.PackageConfig{Filename: "gen/models_gen.go", Package: "gen"},
+		Generate:  &config.GenerateConfig{InputBuilders: true},
+		GQLConfig: &gqlgenconfig.Config{Schema: schema},
+	}
+	pkg := types.NewPackage(cfg.Model.ImportPath(), "gen")
+	profile := types.NewNamed(types.NewTypeName(0, pkg, "ProfileInput", nil), nil, nil)
+	build := &modelgen.ModelBuild{Models: []*modelgen.Object{
+		{
+			Name:   "ProfileInput",
+			Fields: []*modelgen.Field{{Name: "bio", Type: types.NewPointer(types.Typ[types.String]), Tag: `json:"bio"`}},
+		},
+		{
+			Name: "CreateUserInput",
+			Fields: []*modelgen.Field{
+				{Name: "name", Type: types.Typ[types.String], Tag: `json:"name"`},
+				{Name: "profile", Type: optionalOf(t, profile), Tag: `json:"profile,omitzero"`},
+				{Name: "main", Type: profile, Tag: `json:"main"`},
+				{Name: "others", Type: types.NewSlice(types.NewPointer(profile)), Tag: `json:"others"`},
+				{Name: "build", Type: types.NewPointer(types.Typ[types.Int]), Tag: `json:"build"`},
+			},
+		},
+	}}
+
+	b := &inputBuilders{cfg: cfg}
+	b.hook(nil)(build)
+	require.Equal(t, []*builtInput{
+		{Name: "ProfileInput", Setters: []*builderSetter{
+			{Method: "Bio", Field: "Bio", Type: types.Typ[types.String], Wrap: "pointer"},
+		}},
+		{Name: "CreateUserInput", Setters: []*builderSetter{
+			{Method: "Name", Field: "Name", Type: types.Typ[types.String]},
+			{Method: "Profile", Field: "Profile", Wrap: "optional", Nested: "ProfileInput"},
+			{Method: "Main", Field: "Main", Nested: "ProfileInput"},
+			{Method: "Others", Field: "Others", Type: types.NewSlice(types.NewPointer(profile))},
+			{Method: "AddOthers", Field: "Others", Nested: "ProfileInput", List: true, ElemPointer: true},
+			{Method: "SetBuild", Field: "Build", Type: types.Typ[types.Int], Wrap: "pointer"},
+		}},
+	}, b.inputs)
+
+	cfg.Generate.InputBuilders = false
+	b.hook(nil)(build)
+	require.Empty(t, b.inputs)
+}
@@ -0,0 +1,179 @@
+package generator
+
+import (
+	"go/types"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/99designs/gqlgen/plugin/modelgen"
+	"github.com/perchcredit/gqlgenc/clientgen"
+	"github.com/perchcredit/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/xerrors"
+)
+
+// defaultedInput is a generated input type holding fields with a default value in the schema
+type defaultedInput struct {
+	Name   string
+	Fields []*defaultedField
+}
+
+// defaultedField is an input field set to the constant Const by the New<Input> constructor
+type defaultedField struct {
+	Field string
+	Const string
+	// Value is the Go literal of the default, converted to Type when Named
+	Value string
+	Type  types.Type
+	Named bool
+	// Wrap is how the field holds Type: "pointer", "optional" or empty for the value itself
+	Wrap string
+}
+
+// inputDefaults collects the default values of the input fields from the models built by modelgen
+type inputDefaults struct {
+	cfg    *config.Config
+	built  bool
+	inputs []*defaultedInput
+}
+
+// hook records the inputs with default values then calls next
+func (d *inputDefaults) hook(next modelgen.BuildMutateHook) modelgen.BuildMutateHook {
+	return func(b *modelgen.ModelBuild) *modelgen.ModelBuild {
+		if next != nil {
+			b = next(b)
+		}
+
+		d.built = true
+		d.inputs = nil
+		if d.cfg.Generate == nil || !d.cfg.Generate.SchemaDefaults {
+			return b
+		}
+
+		for _, model := range b.Models {
+			definition := d.cfg.GQLConfig.Schema.Types[model.Name]
+			if definition == nil || definition.Kind != ast.InputObject {
+				continue
+			}
+
+			input := &defaultedInput{Name: templates.ToGo(model.Name)}
+			for _, field := range model.Fields {
+				fieldDefinition := definition.Fields.ForName(jsonFieldName(field.Tag))
+				if fieldDefinition == nil || fieldDefinition.DefaultValue == nil {
+					continue
+				}
+
+				typ, wrap := field.Type, ""
+				switch t := typ.(type) {
+				case *types.Pointer:
+					typ, wrap = t.Elem(), "pointer"
+				case *types.Named:
+					if isOptional(t) {
+						typ, wrap = t.TypeArgs().At(0), "optional"
+					}
+				}
+
+				var (
+					value string
+					named bool
+				)
+				if enum := d.cfg.GQLConfig.Schema.Types[fieldDefinition.Type.Name()]; enum != nil && enum.Kind == ast.Enum {
+					// the enums of the models are not built yet, their values are the names of the schema
+					if fieldDefinition.DefaultValue.Kind == ast.EnumValue {
+						value, named = strconv.Quote(fieldDefinition.DefaultValue.Raw), true
+					}
+				} else {
+					value, named = clientgen.DefaultLiteral(fieldDefinition.DefaultValue, typ)
+				}
+				if value == "" {
+					continue
+				}
+
+				input.Fields = append(input.Fields, &defaultedField{
+					Field: templates.ToGo(field.Name),
+					Const: input.Name + templates.ToGo(field.Name) + "Default",
+					Value: value,
+					Type:  typ,
+					Named: named,
+					Wrap:  wrap,
+				})
+			}
+
+			if len(input.Fields) > 0 {
+				d.inputs = append(d.inputs, input)
+			}
+		}
+
+		return b
+	}
+}
+
+func (d *inputDefaults) filename() string {
+	return inputDefaultsFilename(d.cfg)
+}
+
+// inputDefaultsFilename is the file holding the default values of the input fields, next to the models
+func inputDefaultsFilename(cfg *config.Config) string {
+	return strings.TrimSuffix(cfg.Model.Filename, ".go") + "_defaults.go"
+}
+
+// render writes the constants and constructors of the inputs with default values, removing the file when there is none
+func (d *inputDefaults) render() error {
+	if !d.built {
+		return nil
+	}
+
+	if len(d.inputs) == 0 {
+		if err := os.Remove(d.filename()); err != nil && !os.IsNotExist(err) {
+			return xerrors.Errorf("remove %s: %w", filepath.Base(d.filename()), err)
+		}
+
+		return nil
+	}
+
+	if err := templates.Render(templates.Options{
+		PackageName: d.cfg.Model.Package,
+		Filename:    d.filename(),
+		Template:    inputDefaultsTemplate,
+		Data:        d.inputs,
+		Packages:    d.cfg.GQLConfig.Packages,
+		PackageDoc:  "// Code generated by github.com/perchcredit/gqlgenc, DO NOT EDIT.\n",
+	}); err != nil {
+		return xerrors.Errorf("%s generating failed: %w", d.filename(), err)
+	}
+
+	return nil
+}
+
+const inputDefaultsTemplate = `{{ reserveImport "github.com/perchcredit/gqlgenc/client" }}
+
+{{- range $input := . }}
+
+const (
+	{{- range $field := $input.Fields }}
+	// {{ $field.Const }} is the default value of {{ $input.Name }}.{{ $field.Field }} in the schema
+	{{ $field.Const }} = {{ if $field.Named }}{{ $field.Type | ref }}({{ $field.Value }}){{ else }}{{ $field.Value }}{{ end }}
+	{{- end }}
+)
+
+// New{{ $input.Name }} returns a {{ $input.Name }} whose fields with a default value in the schema are set to it
+func New{{ $input.Name }}() *{{ $input.Name }} {
+	var input {{ $input.Name }}
+	{{- range $field := $input.Fields }}
+	{{- if eq $field.Wrap "pointer" }}
+	{{ $field.Field | goPrivate }}Default := {{ if $field.Named }}{{ $field.Const }}{{ else }}{{ $field.Type | ref }}({{ $field.Const }}){{ end }}
+	input.{{ $field.Field }} = &{{ $field.Field | goPrivate }}Default
+	{{- else if eq $field.Wrap "optional" }}
+	input.{{ $field.Field }} = client.OptionalOf({{ if $field.Named }}{{ $field.Const }}{{ else }}{{ $field.Type | ref }}({{ $field.Const }}){{ end }})
+	{{- else }}
+	input.{{ $field.Field }} = {{ $field.Const }}
+	{{- end }}
+	{{- end }}
+
+	return &input
+}
+{{- end }}
+`
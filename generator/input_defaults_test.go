@@ -0,0 +1,55 @@
+package generator
+
+import (
+	"go/types"
+	"testing"
+
+	gqlgenconfig "github.com/99designs/gqlgen/codegen/config"
+	"github.com/99designs/gqlgen/plugin/modelgen"
+	"github.com/perchcredit/gqlgenc/config"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestInputDefaults(t *testing.T) {
+	t.Parallel()
+	schema := gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: `
+		enum Role { ADMIN MEMBER }
+		input UserFilter {
+			role: Role = MEMBER
+			limit: Int = 20
+			active: Boolean! = true
+			name: String
+			tags: [String!] = ["a"]
+		}
+		type Query { users(filter: UserFilter): [String!]! }
+	`})
+	pkg := types.NewPackage("example.com/gen", "gen")
+	role := types.NewNamed(types.NewTypeName(0, pkg, "Role", nil), nil, nil)
+	build := &modelgen.ModelBuild{Models: []*modelgen.Object{{
+		Name: "UserFilter",
+		Fields: []*modelgen.Field{
+			{Name: "role", Type: optionalOf(t, role), Tag: `json:"role,omitzero"`},
+			{Name: "limit", Type: types.NewPointer(types.Typ[types.Int]), Tag: `json:"limit"`},
+			{Name: "active", Type: types.Typ[types.Bool], Tag: `json:"active"`},
+			{Name: "name", Type: types.NewPointer(types.Typ[types.String]), Tag: `json:"name"`},
+			{Name: "tags", Type: types.NewSlice(types.Typ[types.String]), Tag: `json:"tags"`},
+		},
+	}}}
+
+	d := &inputDefaults{cfg: &config.Config{
+		Generate:  &config.GenerateConfig{SchemaDefaults: true},
+		GQLConfig: &gqlgenconfig.Config{Schema: schema},
+	}}
+	d.hook(nil)(build)
+	require.Equal(t, []*defaultedInput{{Name: "UserFilter", Fields: []*defaultedField{
+		{Field: "Role", Const: "UserFilterRoleDefault", Value: `"MEMBER"`, Type: role, Named: true, Wrap: "optional"},
+		{Field: "Limit", Const: "UserFilterLimitDefault", Value: "20", Type: types.Typ[types.Int], Wrap: "pointer"},
+		{Field: "Active", Const: "UserFilterActiveDefault", Value: "true", Type: types.Typ[types.Bool]},
+	}}}, d.inputs)
+
+	d.cfg.Generate.SchemaDefaults = false
+	d.hook(nil)(build)
+	require.Empty(t, d.inputs)
+}
@@ -0,0 +1,216 @@
+package generator
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/perchcredit/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/xerrors"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect of the documents of the input types
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// inputJSONSchemaFilename is the document of the input type name, the nested inputs reference it
+func inputJSONSchemaFilename(name string) string {
+	return name + ".schema.json"
+}
+
+// writeInputJSONSchemas writes a JSON Schema document per input type of the schema to GenerateConfig.InputJSONSchema
+func writeInputJSONSchemas(cfg *config.Config) error {
+	if cfg.Generate == nil || cfg.Generate.InputJSONSchema == "" {
+		return nil
+	}
+
+	dir := cfg.Generate.InputJSONSchema
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return xerrors.Errorf("create %s: %w", dir, err)
+	}
+
+	for _, name := range inputTypeNames(cfg.GQLConfig.Schema) {
+		content, err := json.MarshalIndent(inputJSONSchema(cfg, cfg.GQLConfig.Schema.Types[name]), "", "  ")
+		if err != nil {
+			return xerrors.Errorf("encode %s: %w", name, err)
+		}
+
+		filename := filepath.Join(dir, inputJSONSchemaFilename(name))
+		if err := ioutil.WriteFile(filename, append(content, '\n'), 0o644); err != nil {
+			return xerrors.Errorf("write %s: %w", filename, err)
+		}
+	}
+
+	return nil
+}
+
+// inputTypeNames are the names of the input types of the schema, sorted
+func inputTypeNames(schema *ast.Schema) []string {
+	names := make([]string, 0, len(schema.Types))
+	for name, definition := range schema.Types {
+		if definition.Kind == ast.InputObject && !strings.HasPrefix(name, "__") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// inputJSONSchemaFilenames are the JSON Schema documents in GenerateConfig.InputJSONSchema and the ones of the input
+// types of the schema once it is loaded, sorted
+func inputJSONSchemaFilenames(cfg *config.Config) ([]string, error) {
+	if cfg.Generate == nil || cfg.Generate.InputJSONSchema == "" {
+		return nil, nil
+	}
+
+	dir := cfg.Generate.InputJSONSchema
+	filenames, err := filepath.Glob(filepath.Join(dir, inputJSONSchemaFilename("*")))
+	if err != nil {
+		return nil, xerrors.Errorf("list %s: %w", dir, err)
+	}
+
+	if cfg.GQLConfig != nil && cfg.GQLConfig.Schema != nil {
+		for _, name := range inputTypeNames(cfg.GQLConfig.Schema) {
+			filename := filepath.Join(dir, inputJSONSchemaFilename(name))
+			if !config.StringList(filenames).Has(filename) {
+				filenames = append(filenames, filename)
+			}
+		}
+	}
+	sort.Strings(filenames)
+
+	return filenames, nil
+}
+
+// inputJSONSchema is the JSON Schema of an input type, its non-null fields without default value being required.
+// Exactly one field of a @oneOf input is set.
+func inputJSONSchema(cfg *config.Config, definition *ast.Definition) map[string]interface{} {
+	properties := make(map[string]interface{}, len(definition.Fields))
+	required := []string{}
+	oneOf := definition.Directives.ForName("oneOf") != nil
+	for _, field := range definition.Fields {
+		property := typeJSONSchema(cfg, field.Type)
+		if field.Description != "" {
+			property["description"] = field.Description
+		}
+		if field.DefaultValue != nil {
+			if value, err := field.DefaultValue.Value(nil); err == nil {
+				if wire, ok := cfg.Generate.EnumValues[field.Type.Name()][field.DefaultValue.Raw]; ok && field.DefaultValue.Kind == ast.EnumValue {
+					value = wire
+				}
+				property["default"] = value
+			}
+		}
+		properties[field.Name] = property
+
+		if field.Type.NonNull && field.DefaultValue == nil && !oneOf {
+			required = append(required, field.Name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"$schema":              jsonSchemaDraft,
+		"$id":                  inputJSONSchemaFilename(definition.Name),
+		"title":                definition.Name,
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	if definition.Description != "" {
+		schema["description"] = definition.Description
+	}
+	if oneOf {
+		schema["minProperties"] = 1
+		schema["maxProperties"] = 1
+	}
+
+	return schema
+}
+
+// typeJSONSchema is the JSON Schema of the values of typ, null included when typ is nullable
+func typeJSONSchema(cfg *config.Config, typ *ast.Type) map[string]interface{} {
+	var schema map[string]interface{}
+	if typ.Elem != nil {
+		schema = map[string]interface{}{"type": "array", "items": typeJSONSchema(cfg, typ.Elem)}
+	} else {
+		schema = namedJSONSchema(cfg, typ.NamedType)
+	}
+
+	if typ.NonNull {
+		return schema
+	}
+
+	return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+}
+
+// namedJSONSchema is the JSON Schema of the non-null values of the named type
+func namedJSONSchema(cfg *config.Config, name string) map[string]interface{} {
+	definition := cfg.GQLConfig.Schema.Types[name]
+	if definition == nil {
+		return map[string]interface{}{}
+	}
+
+	switch definition.Kind {
+	case ast.InputObject:
+		return map[string]interface{}{"$ref": inputJSONSchemaFilename(name)}
+	case ast.Enum:
+		// the enums of GenerateConfig.EnumValues are sent as integers
+		wire, ok := cfg.Generate.EnumValues[name]
+		values := make([]interface{}, 0, len(definition.EnumValues))
+		for _, value := range definition.EnumValues {
+			if ok {
+				values = append(values, wire[value.Name])
+			} else {
+				values = append(values, value.Name)
+			}
+		}
+		if ok {
+			return map[string]interface{}{"type": "integer", "enum": values}
+		}
+
+		return map[string]interface{}{"type": "string", "enum": values}
+	}
+
+	return scalarJSONSchema(cfg, name)
+}
+
+// scalarJSONSchema is the JSON Schema of a scalar, from the Go types it is mapped to for the custom scalars,
+// any value when they have no JSON Schema counterpart
+func scalarJSONSchema(cfg *config.Config, name string) map[string]interface{} {
+	switch name {
+	case "Int":
+		return map[string]interface{}{"type": "integer"}
+	case "Float":
+		return map[string]interface{}{"type": "number"}
+	case "String", "ID":
+		return map[string]interface{}{"type": "string"}
+	case "Boolean":
+		return map[string]interface{}{"type": "boolean"}
+	}
+
+	for _, model := range cfg.GQLConfig.Models[name].Model {
+		switch strings.TrimPrefix(model, "github.com/99designs/gqlgen/graphql.") {
+		case "Int", "Int32", "Int64", "Uint", "Uint32", "Uint64":
+			return map[string]interface{}{"type": "integer"}
+		case "Float":
+			return map[string]interface{}{"type": "number"}
+		case "String", "ID", "IntID":
+			return map[string]interface{}{"type": "string"}
+		case "Boolean":
+			return map[string]interface{}{"type": "boolean"}
+		case "Time", "time.Time":
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		case "Map":
+			return map[string]interface{}{"type": "object"}
+		case "Upload":
+			return map[string]interface{}{"type": "string", "format": "binary"}
+		}
+	}
+
+	return map[string]interface{}{}
+}
@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	gqlgenconfig "github.com/99designs/gqlgen/codegen/config"
+	"github.com/perchcredit/gqlgenc/config"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestInputJSONSchema(t *testing.T) {
+	t.Parallel()
+	schema := gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: `
+		directive @oneOf on INPUT_OBJECT
+		scalar Time
+		scalar JSON
+		enum Role { ADMIN MEMBER }
+		"The profile of a user"
+		input ProfileInput { bio: String }
+		input CreateUserInput {
+			"The displayed name"
+			name: String!
+			age: Int
+			role: Role! = MEMBER
+			createdAt: Time
+			extra: JSON
+			profiles: [ProfileInput!]
+		}
+		input UserLookup @oneOf { id: ID, email: String }
+		type Query { createUser(input: CreateUserInput!, lookup: UserLookup): String }
+	`})
+	dir := filepath.Join(t.TempDir(), "schemas")
+	cfg := &config.Config{
+		Generate: &config.GenerateConfig{InputJSONSchema: dir},
+		GQLConfig: &gqlgenconfig.Config{Schema: schema, Models: gqlgenconfig.TypeMap{
+			"Time": {Model: gqlgenconfig.StringList{"github.com/99designs/gqlgen/graphql.Time"}},
+		}},
+	}
+	require.NoError(t, writeInputJSONSchemas(cfg))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	require.Equal(t, []string{"CreateUserInput.schema.json", "ProfileInput.schema.json", "UserLookup.schema.json"}, names)
+
+	content, err := os.ReadFile(filepath.Join(dir, "CreateUserInput.schema.json"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "CreateUserInput.schema.json",
+		"title": "CreateUserInput",
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "description": "The displayed name"},
+			"age": {"anyOf": [{"type": "integer"}, {"type": "null"}]},
+			"role": {"type": "string", "enum": ["ADMIN", "MEMBER"], "default": "MEMBER"},
+			"createdAt": {"anyOf": [{"type": "string", "format": "date-time"}, {"type": "null"}]},
+			"extra": {"anyOf": [{}, {"type": "null"}]},
+			"profiles": {"anyOf": [{"type": "array", "items": {"$ref": "ProfileInput.schema.json"}}, {"type": "null"}]}
+		},
+		"required": ["name"],
+		"additionalProperties": false
+	}`, string(content))
+
+	content, err = os.ReadFile(filepath.Join(dir, "UserLookup.schema.json"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "UserLookup.schema.json",
+		"title": "UserLookup",
+		"type": "object",
+		"properties": {
+			"id": {"anyOf": [{"type": "string"}, {"type": "null"}]},
+			"email": {"anyOf": [{"type": "string"}, {"type": "null"}]}
+		},
+		"required": [],
+		"additionalProperties": false,
+		"minProperties": 1,
+		"maxProperties": 1
+	}`, string(content))
+
+	content, err = os.ReadFile(filepath.Join(dir, "ProfileInput.schema.json"))
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"description": "The profile of a user"`)
+
+	cfg.Generate.EnumValues = map[string]map[string]int{"Role": {"ADMIN": 1, "MEMBER": 2}}
+	require.Equal(t, map[string]interface{}{"type": "integer", "enum": []interface{}{1, 2}, "default": 2},
+		inputJSONSchema(cfg, schema.Types["CreateUserInput"])["properties"].(map[string]interface{})["role"])
+}
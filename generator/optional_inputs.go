@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"go/types"
+	"strings"
+
+	"github.com/99designs/gqlgen/plugin/modelgen"
+	"github.com/perchcredit/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/xerrors"
+)
+
+// optionalType is the generic client.Optional type
+var optionalType = func() *types.Named {
+	pkg := types.NewPackage("github.com/perchcredit/gqlgenc/client", "client")
+	typeParam := types.NewTypeParam(types.NewTypeName(0, pkg, "T", nil), types.NewInterfaceType(nil, nil))
+	named := types.NewNamed(types.NewTypeName(0, pkg, "Optional", nil), types.NewStruct(nil, nil), nil)
+	named.SetTypeParams([]*types.TypeParam{typeParam})
+
+	return named
+}()
+
+// optionalInputs wraps the nullable fields of the generated input types in client.Optional
+// so an omitted field can be told apart from an explicit null
+type optionalInputs struct {
+	cfg *config.Config
+	// err is the error of the last build, modelgen hooks cannot return one
+	err error
+}
+
+// hook wraps the nullable input fields of the models built by modelgen
+func (o *optionalInputs) hook() modelgen.BuildMutateHook {
+	return func(b *modelgen.ModelBuild) *modelgen.ModelBuild {
+		o.err = nil
+		for _, model := range b.Models {
+			definition := o.cfg.GQLConfig.Schema.Types[model.Name]
+			if definition == nil || definition.Kind != ast.InputObject {
+				continue
+			}
+
+			for _, field := range model.Fields {
+				name := jsonFieldName(field.Tag)
+				fieldDefinition := definition.Fields.ForName(name)
+				if fieldDefinition == nil || fieldDefinition.Type.NonNull {
+					continue
+				}
+
+				typ, err := optional(field.Type)
+				if err != nil {
+					o.err = xerrors.Errorf("%s.%s: %w", model.Name, name, err)
+
+					return b
+				}
+				field.Type = typ
+				field.Tag = `json:"` + name + `,omitzero"`
+			}
+		}
+
+		return b
+	}
+}
+
+// optional returns client.Optional of the type a nullable field points to
+func optional(typ types.Type) (types.Type, error) {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+
+	instance, err := types.Instantiate(nil, optionalType, []types.Type{typ}, false)
+	if err != nil {
+		return nil, xerrors.Errorf("instantiate client.Optional: %w", err)
+	}
+
+	return instance, nil
+}
+
+// jsonFieldName returns the name of the json tag generated by modelgen
+func jsonFieldName(tag string) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(tag, `json:"`), `"`)
+	if i := strings.Index(name, ","); i != -1 {
+		name = name[:i]
+	}
+
+	return name
+}
@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"go/types"
+	"testing"
+
+	gqlgenconfig "github.com/99designs/gqlgen/codegen/config"
+	"github.com/99designs/gqlgen/plugin/modelgen"
+	"github.com/perchcredit/gqlgenc/config"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// optionalOf returns client.Optional of typ
+func optionalOf(t *testing.T, typ types.Type) types.Type {
+	t.Helper()
+	optionalTyp, err := optional(typ)
+	require.NoError(t, err)
+
+	return optionalTyp
+}
+
+func TestOptionalInputs(t *testing.T) {
+	t.Parallel()
+	schema := gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: `
+		input UserFilter {
+			name: String
+			active: Boolean!
+			tags: [String!]
+		}
+		type User { name: String }
+		type Query { users(filter: UserFilter): [User!]! }
+	`})
+	name := &modelgen.Field{Name: "name", Type: types.NewPointer(types.Typ[types.String]), Tag: `json:"name"`}
+	active := &modelgen.Field{Name: "active", Type: types.Typ[types.Bool], Tag: `json:"active"`}
+	tags := &modelgen.Field{Name: "tags", Type: types.NewSlice(types.Typ[types.String]), Tag: `json:"tags"`}
+	userName := &modelgen.Field{Name: "name", Type: types.NewPointer(types.Typ[types.String]), Tag: `json:"name"`}
+	build := &modelgen.ModelBuild{Models: []*modelgen.Object{
+		{Name: "UserFilter", Fields: []*modelgen.Field{name, active, tags}},
+		{Name: "User", Fields: []*modelgen.Field{userName}},
+	}}
+
+	o := &optionalInputs{cfg: &config.Config{GQLConfig: &gqlgenconfig.Config{Schema: schema}}}
+	o.hook()(build)
+	require.NoError(t, o.err)
+
+	require.Equal(t, "github.com/perchcredit/gqlgenc/client.Optional[string]", name.Type.String())
+	require.Equal(t, `json:"name,omitzero"`, name.Tag)
+	require.Equal(t, "github.com/perchcredit/gqlgenc/client.Optional[[]string]", tags.Type.String())
+	require.Equal(t, `json:"tags,omitzero"`, tags.Tag)
+	// non-null input fields and the fields of the other types are left as they are
+	require.Equal(t, types.Typ[types.Bool], active.Type)
+	require.Equal(t, `json:"active"`, active.Tag)
+	require.Equal(t, "*string", userName.Type.String())
+	require.Equal(t, `json:"name"`, userName.Tag)
+}
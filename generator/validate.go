@@ -0,0 +1,306 @@
+package generator
+
+import (
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/99designs/gqlgen/plugin/modelgen"
+	"github.com/perchcredit/gqlgenc/config"
+	"github.com/vektah/gqlparser/v2/ast"
+	"golang.org/x/xerrors"
+)
+
+// defaultConstraintDirective is the directive holding the constraints of input fields when none is configured
+const defaultConstraintDirective = "constraint"
+
+// validatedInput is a generated input type declared with the @oneOf directive or holding constrained fields
+type validatedInput struct {
+	Name  string
+	OneOf bool
+	// PointerReceiver is set when Validate has a pointer receiver
+	PointerReceiver bool
+	// Set are the conditions of the fields being set, one per field of a @oneOf input
+	Set         []string
+	Constraints []*constraint
+}
+
+// constraint is a check of a constrained input field
+type constraint struct {
+	// Violated is the condition of the field value breaking the constraint
+	Violated string
+	// Message describes the constraint, followed by Got when set
+	Message string
+	Got     string
+	// Pattern is the regular expression of a pattern constraint, compiled once in PatternVar
+	Pattern    string
+	PatternVar string
+}
+
+// inputValidators collects the input types to validate from the models built by modelgen
+type inputValidators struct {
+	cfg    *config.Config
+	built  bool
+	inputs []*validatedInput
+	err    error
+}
+
+// hook records the inputs to validate then calls next
+func (v *inputValidators) hook(next modelgen.BuildMutateHook) modelgen.BuildMutateHook {
+	return func(b *modelgen.ModelBuild) *modelgen.ModelBuild {
+		if next != nil {
+			b = next(b)
+		}
+
+		v.built = true
+		v.inputs = nil
+		v.err = nil
+		for _, model := range b.Models {
+			definition := v.cfg.GQLConfig.Schema.Types[model.Name]
+			if definition == nil || definition.Kind != ast.InputObject {
+				continue
+			}
+
+			input := &validatedInput{
+				Name:            templates.ToGo(model.Name),
+				OneOf:           definition.Directives.ForName("oneOf") != nil,
+				PointerReceiver: v.cfg.Generate.PointerReceiver(false),
+			}
+			for _, field := range model.Fields {
+				if input.OneOf {
+					if set := fieldSet("i."+templates.ToGo(field.Name), field.Type); set != "" {
+						input.Set = append(input.Set, set)
+					}
+				}
+
+				fieldDefinition := definition.Fields.ForName(jsonFieldName(field.Tag))
+				if fieldDefinition == nil {
+					continue
+				}
+
+				directive := fieldDefinition.Directives.ForName(v.constraintDirective())
+				if directive == nil {
+					continue
+				}
+
+				constraints, err := fieldConstraints(input.Name, fieldDefinition.Name, templates.ToGo(field.Name), field.Type, directive)
+				if err != nil {
+					v.err = err
+
+					return b
+				}
+				input.Constraints = append(input.Constraints, constraints...)
+			}
+
+			if input.OneOf || len(input.Constraints) > 0 {
+				v.inputs = append(v.inputs, input)
+			}
+		}
+
+		return b
+	}
+}
+
+func (v *inputValidators) constraintDirective() string {
+	if v.cfg.Generate != nil && v.cfg.Generate.ConstraintDirective != "" {
+		return v.cfg.Generate.ConstraintDirective
+	}
+
+	return defaultConstraintDirective
+}
+
+// fieldSet returns the condition of a nullable field being set to a value other than null
+func fieldSet(expr string, typ types.Type) string {
+	switch typ := typ.(type) {
+	case *types.Pointer, *types.Slice, *types.Map:
+		return expr + " != nil"
+	case *types.Named:
+		if isOptional(typ) {
+			return expr + ".IsSet() && !" + expr + ".IsNull()"
+		}
+	}
+
+	return ""
+}
+
+func isOptional(typ *types.Named) bool {
+	obj := typ.Origin().Obj()
+
+	return obj.Pkg() != nil && obj.Pkg().Path() == optionalType.Obj().Pkg().Path() && obj.Name() == optionalType.Obj().Name()
+}
+
+// fieldValue returns the condition of a field holding a value, empty when it always does,
+// the expression of its value and the basic type of the value
+func fieldValue(expr string, typ types.Type) (guard, value string, basic *types.Basic) {
+	switch t := typ.(type) {
+	case *types.Pointer:
+		guard, value, typ = expr+" != nil", "*"+expr, t.Elem()
+	case *types.Named:
+		if isOptional(t) {
+			guard, value, typ = expr+".IsSet()", expr+".Value()", t.TypeArgs().At(0)
+		} else {
+			value = expr
+		}
+	default:
+		value = expr
+	}
+
+	basic, _ = typ.Underlying().(*types.Basic)
+
+	return guard, value, basic
+}
+
+// fieldConstraints returns the checks of the arguments of the constraint directive of a field
+func fieldConstraints(inputName, fieldName, goFieldName string, typ types.Type, directive *ast.Directive) ([]*constraint, error) {
+	guard, value, basic := fieldValue("i."+goFieldName, typ)
+	if guard != "" {
+		guard += " && "
+	}
+	isString := basic != nil && basic.Info()&types.IsString != 0
+	isNumeric := basic != nil && basic.Info()&types.IsNumeric != 0
+	isInteger := basic != nil && basic.Info()&types.IsInteger != 0
+	name := inputName + "." + fieldName
+
+	constraints := make([]*constraint, 0, len(directive.Arguments))
+	for _, argument := range directive.Arguments {
+		raw := argument.Value.Raw
+		switch argument.Name {
+		case "minLength", "maxLength":
+			if !isString || argument.Value.Kind != ast.IntValue {
+				return nil, xerrors.Errorf("%s: @%s(%s) needs a String field and an Int argument", name, directive.Name, argument.Name)
+			}
+
+			length := "utf8.RuneCountInString(" + value + ")"
+			c := &constraint{Violated: guard + length + " < " + raw, Message: name + " must be at least " + raw + " characters long", Got: length}
+			if argument.Name == "maxLength" {
+				c = &constraint{Violated: guard + length + " > " + raw, Message: name + " must be at most " + raw + " characters long", Got: length}
+			}
+			constraints = append(constraints, c)
+		case "min", "max":
+			if !isNumeric || (argument.Value.Kind != ast.IntValue && argument.Value.Kind != ast.FloatValue) ||
+				(isInteger && argument.Value.Kind == ast.FloatValue) {
+				return nil, xerrors.Errorf("%s: @%s(%s) needs a numeric field and argument", name, directive.Name, argument.Name)
+			}
+
+			c := &constraint{Violated: guard + value + " < " + raw, Message: name + " must be at least " + raw, Got: value}
+			if argument.Name == "max" {
+				c = &constraint{Violated: guard + value + " > " + raw, Message: name + " must be at most " + raw, Got: value}
+			}
+			constraints = append(constraints, c)
+		case "pattern":
+			if !isString || argument.Value.Kind != ast.StringValue {
+				return nil, xerrors.Errorf("%s: @%s(%s) needs a String field and argument", name, directive.Name, argument.Name)
+			}
+
+			patternVar := templates.LcFirst(inputName) + goFieldName + "Pattern"
+			constraints = append(constraints, &constraint{
+				Violated:   guard + "!" + patternVar + ".MatchString(" + value + ")",
+				Message:    name + " must match " + raw,
+				Pattern:    raw,
+				PatternVar: patternVar,
+			})
+		default:
+			return nil, xerrors.Errorf("%s: unknown @%s argument %s", name, directive.Name, argument.Name)
+		}
+	}
+
+	return constraints, nil
+}
+
+// Format is the format of the error reporting the constraint violated
+func (c *constraint) Format() string {
+	format := strings.ReplaceAll(c.Message, "%", "%%")
+	if c.Got != "" {
+		format += ", got %v"
+	}
+
+	return format + ": %w"
+}
+
+func (v *inputValidators) filename() string {
+	return validateFilename(v.cfg)
+}
+
+// validateFilename is the file holding the validation of the input types, next to the models
+func validateFilename(cfg *config.Config) string {
+	return strings.TrimSuffix(cfg.Model.Filename, ".go") + "_validate.go"
+}
+
+// render writes the Validate methods of the inputs, removing the file when there is none
+func (v *inputValidators) render() error {
+	if !v.built {
+		return nil
+	}
+
+	if v.err != nil {
+		return v.err
+	}
+
+	if len(v.inputs) == 0 {
+		if err := os.Remove(v.filename()); err != nil && !os.IsNotExist(err) {
+			return xerrors.Errorf("remove %s: %w", filepath.Base(v.filename()), err)
+		}
+
+		return nil
+	}
+
+	if err := templates.Render(templates.Options{
+		PackageName: v.cfg.Model.Package,
+		Filename:    v.filename(),
+		Template:    validateTemplate,
+		Data:        v.inputs,
+		Packages:    v.cfg.GQLConfig.Packages,
+		PackageDoc:  "// Code generated by github.com/perchcredit/gqlgenc, DO NOT EDIT.\n",
+	}); err != nil {
+		return xerrors.Errorf("%s generating failed: %w", v.filename(), err)
+	}
+
+	return nil
+}
+
+const validateTemplate = `{{ reserveImport "fmt" }}
+{{ reserveImport "regexp" }}
+{{ reserveImport "unicode/utf8" }}
+{{ reserveImport "github.com/perchcredit/gqlgenc/client" }}
+
+{{- range $input := . }}
+{{- range $constraint := $input.Constraints }}
+{{- if $constraint.PatternVar }}
+
+var {{ $constraint.PatternVar }} = regexp.MustCompile({{ $constraint.Pattern | quote }})
+{{- end }}
+{{- end }}
+
+var _ client.Validator = (*{{ $input.Name }})(nil)
+
+{{- if $input.OneOf }}
+
+// Validate checks that exactly one field of the @oneOf input {{ $input.Name }} is set
+{{- else }}
+
+// Validate checks the constraints of the fields of {{ $input.Name }}
+{{- end }}
+func (i {{ if $input.PointerReceiver }}*{{ end }}{{ $input.Name }}) Validate() error {
+	{{- if $input.OneOf }}
+	set := 0
+	{{- range $set := $input.Set }}
+	if {{ $set }} {
+		set++
+	}
+	{{- end }}
+	if set != 1 {
+		return fmt.Errorf("{{ $input.Name }} has %d fields set: %w", set, client.ErrOneOf)
+	}
+	{{- end }}
+	{{- range $constraint := $input.Constraints }}
+	if {{ $constraint.Violated }} {
+		return fmt.Errorf({{ $constraint.Format | quote }}{{ with $constraint.Got }}, {{ . }}{{ end }}, client.ErrConstraint)
+	}
+	{{- end }}
+
+	return nil
+}
+{{- end }}
+`
@@ -0,0 +1,234 @@
+package graphqljson
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// MarshalData encodes the GraphQL query data structure v into the response data UnmarshalData decodes it from:
+// the fields of the fragments and embedded structs are merged into their object and the keys are the response
+// names, in the order of the fields. The fields of an inline fragment on another type than the __typename of
+// its object are only encoded when set, as the decoder leaves the ones missing from the response zero.
+func MarshalData(v interface{}) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	if err := encodeValue(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, xerrors.Errorf(": %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// gqlMarshaler is the marshaling of the custom scalars and enums of gqlgen, used for the types not implementing
+// json.Marshaler.
+type gqlMarshaler interface {
+	MarshalGQL(w io.Writer)
+}
+
+var (
+	gqlMarshalerType = reflect.TypeOf((*gqlMarshaler)(nil)).Elem()
+	marshalerType    = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// encodeValue writes the JSON encoding of v to buf.
+func encodeValue(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteString("null")
+
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+
+			return nil
+		}
+
+		return encodeValue(buf, v.Elem())
+	}
+
+	t := v.Type()
+	if reflect.PtrTo(t).Implements(marshalerType) || reflect.PtrTo(t).Implements(gqlMarshalerType) {
+		return encodeScalar(buf, v)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return encodeObject(buf, v)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return encodeScalar(buf, v)
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteString("null")
+
+			return nil
+		}
+
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeValue(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+
+		return nil
+	}
+
+	return encodeScalar(buf, v)
+}
+
+// encodeScalar writes the JSON encoding of a leaf value, with its MarshalJSON or MarshalGQL method if any.
+func encodeScalar(buf *bytes.Buffer, v reflect.Value) error {
+	// a pointer to a copy of v reaches the methods of pointer receivers
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+
+	if !p.Type().Implements(marshalerType) {
+		if marshaler, ok := p.Interface().(gqlMarshaler); ok {
+			marshaler.MarshalGQL(buf)
+
+			return nil
+		}
+	}
+
+	b, err := json.Marshal(p.Interface())
+	if err != nil {
+		return xerrors.Errorf("encode %s: %w", v.Type(), err)
+	}
+	buf.Write(b)
+
+	return nil
+}
+
+// responseField is a field of an object in the response data.
+type responseField struct {
+	key   string
+	value reflect.Value
+}
+
+// encodeObject writes the JSON object of the selection struct v.
+func encodeObject(buf *bytes.Buffer, v reflect.Value) error {
+	var fields []responseField
+	collectFields(v, typenameOf(v), false, map[string]bool{}, &fields)
+
+	buf.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.key)
+		if err != nil {
+			return xerrors.Errorf(": %w", err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err := encodeValue(buf, field.value); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+
+	return nil
+}
+
+// collectFields appends the response fields of struct v to fields, merging the ones of its fragments and embedded
+// structs. Fields already collected are skipped, so are the zero ones when skipZero is set.
+func collectFields(v reflect.Value, typename string, skipZero bool, seen map[string]bool, fields *[]responseField) {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		fv := v.Field(i)
+
+		if isGraphQLFragment(f) || (f.Anonymous && responseKey(f) == f.Name) {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() != reflect.Struct {
+				continue
+			}
+
+			condition := fragmentTypeCondition(f)
+			collectFields(fv, typename, skipZero || (typename != "" && condition != "" && condition != typename), seen, fields)
+
+			continue
+		}
+
+		if f.PkgPath != "" {
+			// Skip unexported field.
+			continue
+		}
+
+		key := responseKey(f)
+		if key == "-" || seen[key] || (skipZero && fv.IsZero()) {
+			continue
+		}
+		seen[key] = true
+		*fields = append(*fields, responseField{key: key, value: fv})
+	}
+}
+
+// typenameOf returns the __typename selected in struct v, empty when it is not selected.
+func typenameOf(v reflect.Value) string {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.PkgPath == "" && responseKey(f) == "__typename" && v.Field(i).Kind() == reflect.String {
+			return v.Field(i).String()
+		}
+	}
+
+	return ""
+}
+
+// responseKey returns the key of struct field f in the response data: its json name, or its GraphQL name or alias,
+// or the name of the field.
+func responseKey(f reflect.StructField) string {
+	if value, ok := f.Tag.Lookup("json"); ok {
+		if name := strings.Split(value, ",")[0]; name != "" {
+			return name
+		}
+	}
+
+	if value, ok := f.Tag.Lookup("graphql"); ok {
+		value = strings.TrimSpace(value)
+		if i := strings.Index(value, "("); i != -1 {
+			value = value[:i]
+		}
+		if i := strings.Index(value, ":"); i != -1 {
+			value = value[:i]
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+
+	return f.Name
+}
+
+// fragmentTypeCondition returns the type of the inline fragment struct field f, empty for the fragment spreads and
+// the embedded structs.
+func fragmentTypeCondition(f reflect.StructField) string {
+	value := strings.TrimSpace(f.Tag.Get("graphql"))
+	if !strings.HasPrefix(value, "...") {
+		return ""
+	}
+
+	value = strings.TrimSpace(strings.TrimPrefix(value, "..."))
+	if !strings.HasPrefix(value, "on ") {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(value, "on "))
+}
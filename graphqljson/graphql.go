@@ -38,13 +38,36 @@ import (
 
 // Reference: https://blog.gopheracademy.com/advent-2017/custom-json-unmarshaler-for-graphql-client/
 
+// Option configures how UnmarshalData decodes the response data.
+type Option func(d *Decoder)
+
+// DisallowUnknownFields makes UnmarshalData fail on response fields missing from
+// the GraphQL query data structure, they are skipped by default.
+func DisallowUnknownFields() Option {
+	return func(d *Decoder) {
+		d.disallowUnknownFields = true
+	}
+}
+
+// UseNumber makes UnmarshalData decode the numbers stored in interface{} values, such as the ones of
+// maps and custom scalars mapped to interface{}, as json.Number instead of float64, keeping the precision
+// of big integers and decimals.
+func UseNumber() Option {
+	return func(d *Decoder) {
+		d.useNumber = true
+	}
+}
+
 // UnmarshalData parses the JSON-encoded GraphQL response data and stores
 // the result in the GraphQL query data structure pointed to by v.
 //
 // The implementation is created on top of the JSON tokenizer available
 // in "encoding/json".Decoder.
-func UnmarshalData(data json.RawMessage, v interface{}) error {
+func UnmarshalData(data json.RawMessage, v interface{}, opts ...Option) error {
 	d := newDecoder(bytes.NewBuffer(data))
+	for _, opt := range opts {
+		opt(d)
+	}
 	if err := d.Decode(v); err != nil {
 		return xerrors.Errorf(": %w", err)
 	}
@@ -78,6 +101,12 @@ type Decoder struct {
 	// a single JSON value into multiple GraphQL fragments or embedded structs, so
 	// we keep track of them all.
 	vs [][]reflect.Value
+
+	// Whether a response field missing from every place to unmarshal is an error rather than skipped.
+	disallowUnknownFields bool
+
+	// Whether the numbers stored in interface{} values are json.Number rather than float64.
+	useNumber bool
 }
 
 func newDecoder(r io.Reader) *Decoder {
@@ -125,22 +154,34 @@ func (d *Decoder) decode() error {
 			}
 
 			someFieldExist := false
+			fields := make([]reflect.Value, len(d.vs))
 			for i := range d.vs {
 				v := d.vs[i][len(d.vs[i])-1]
 				if v.Kind() == reflect.Ptr {
 					v = v.Elem()
 				}
-				var f reflect.Value
 				if v.Kind() == reflect.Struct {
-					f = fieldByGraphQLName(v, key)
-					if f.IsValid() {
+					fields[i] = fieldByGraphQLName(v, key)
+					if fields[i].IsValid() {
 						someFieldExist = true
 					}
 				}
-				d.vs[i] = append(d.vs[i], f)
 			}
 			if !someFieldExist {
-				return xerrors.Errorf("struct field for %q doesn't exist in any of %v places to unmarshal", key, len(d.vs))
+				if d.disallowUnknownFields {
+					return xerrors.Errorf("struct field for %q doesn't exist in any of %v places to unmarshal", key, len(d.vs))
+				}
+
+				// Skip the value of the unknown field.
+				var skipped json.RawMessage
+				if err := d.jsonDecoder.Decode(&skipped); err != nil {
+					return xerrors.Errorf(": %w", err)
+				}
+
+				continue
+			}
+			for i := range d.vs {
+				d.vs[i] = append(d.vs[i], fields[i])
 			}
 
 			// We've just consumed the current token, which was the key.
@@ -182,7 +223,7 @@ func (d *Decoder) decode() error {
 				if !v.IsValid() {
 					continue
 				}
-				err := unmarshalValue(tok, v)
+				err := d.unmarshalValue(tok, v)
 				if err != nil {
 					return xerrors.Errorf(": %w", err)
 				}
@@ -190,6 +231,31 @@ func (d *Decoder) decode() error {
 			d.popAllVs()
 
 		case json.Delim:
+			switch tok {
+			case '{', '[':
+				if !d.rawTargets() {
+					break
+				}
+
+				// Object or array decoded as a whole by json.Unmarshaler or map/interface targets.
+				raw, err := d.rawValue(tok)
+				if err != nil {
+					return xerrors.Errorf(": %w", err)
+				}
+				for i := range d.vs {
+					v := d.vs[i][len(d.vs[i])-1]
+					if !v.IsValid() {
+						continue
+					}
+					if err := d.unmarshal(raw, v.Addr().Interface()); err != nil {
+						return xerrors.Errorf(": %w", err)
+					}
+				}
+				d.popAllVs()
+
+				continue
+			}
+
 			switch tok {
 			case '{':
 				// Start of object.
@@ -218,9 +284,15 @@ func (d *Decoder) decode() error {
 					}
 					for i := 0; i < v.NumField(); i++ {
 						if isGraphQLFragment(v.Type().Field(i)) || v.Type().Field(i).Anonymous {
+							// Allocate embedded struct pointers, their fields are in the same JSON object.
+							f := v.Field(i)
+							if f.Kind() == reflect.Ptr && f.IsNil() && f.CanSet() && f.Type().Elem().Kind() == reflect.Struct {
+								f.Set(reflect.New(f.Type().Elem())) // f = new(T).
+							}
+
 							// Add GraphQL fragment or embedded struct.
-							d.vs = append(d.vs, []reflect.Value{v.Field(i)})
-							frontier = append(frontier, v.Field(i))
+							d.vs = append(d.vs, []reflect.Value{f})
+							frontier = append(frontier, f)
 						}
 					}
 				}
@@ -260,6 +332,80 @@ func (d *Decoder) decode() error {
 	return nil
 }
 
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// rawTargets reports whether every place to unmarshal the next value decodes whole JSON values itself,
+// either implementing json.Unmarshaler or being a map or an interface.
+func (d *Decoder) rawTargets() bool {
+	found := false
+	for i := range d.vs {
+		v := d.vs[i][len(d.vs[i])-1]
+		if !v.IsValid() {
+			continue
+		}
+
+		t := v.Type()
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if !reflect.PtrTo(t).Implements(unmarshalerType) && t.Kind() != reflect.Map && t.Kind() != reflect.Interface {
+			return false
+		}
+		found = true
+	}
+
+	return found
+}
+
+// rawValue reads the rest of the object or array opened by the delimiter open and returns it as JSON.
+func (d *Decoder) rawValue(open json.Delim) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	buf.WriteString(open.String())
+
+	// Delimiters of the enclosing values and the count of tokens read in each of them.
+	delims := []json.Delim{open}
+	counts := []int{0}
+	for len(delims) > 0 {
+		tok, err := d.jsonDecoder.Token()
+		if err == io.EOF {
+			return nil, xerrors.New("unexpected end of JSON input")
+		} else if err != nil {
+			return nil, xerrors.Errorf(": %w", err)
+		}
+
+		if tok == json.Delim('}') || tok == json.Delim(']') {
+			buf.WriteString(tok.(json.Delim).String())
+			delims, counts = delims[:len(delims)-1], counts[:len(counts)-1]
+
+			continue
+		}
+
+		top := len(delims) - 1
+		switch {
+		case delims[top] == '{' && counts[top]%2 == 1:
+			buf.WriteByte(':')
+		case counts[top] > 0:
+			buf.WriteByte(',')
+		}
+		counts[top]++
+
+		if delim, ok := tok.(json.Delim); ok {
+			buf.WriteString(delim.String())
+			delims, counts = append(delims, delim), append(counts, 0)
+
+			continue
+		}
+
+		b, err := json.Marshal(tok)
+		if err != nil {
+			return nil, xerrors.Errorf(": %w", err)
+		}
+		buf.Write(b)
+	}
+
+	return buf.Bytes(), nil
+}
+
 // pushState pushes a new parse state s onto the stack.
 func (d *Decoder) pushState(s json.Delim) {
 	d.parseState = append(d.parseState, s)
@@ -345,11 +491,56 @@ func isGraphQLFragment(f reflect.StructField) bool {
 // unmarshalValue unmarshals JSON value into v.
 // v must be addressable and not obtained by the use of unexported
 // struct fields, otherwise unmarshalValue will panic.
-func unmarshalValue(value json.Token, v reflect.Value) error {
+func (d *Decoder) unmarshalValue(value json.Token, v reflect.Value) error {
+	if value != nil {
+		if unmarshaler, ok := gqlUnmarshalerOf(v); ok {
+			return unmarshaler.UnmarshalGQL(value)
+		}
+	}
+
 	b, err := json.Marshal(value) // TODO: Short-circuit (if profiling says it's worth it).
 	if err != nil {
 		return xerrors.Errorf(": %w", err)
 	}
 
-	return json.Unmarshal(b, v.Addr().Interface())
+	return d.unmarshal(b, v.Addr().Interface())
+}
+
+// unmarshal decodes the JSON value b into v, storing numbers as json.Number in interface{} values with useNumber.
+func (d *Decoder) unmarshal(b []byte, v interface{}) error {
+	if !d.useNumber {
+		return json.Unmarshal(b, v)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	return decoder.Decode(v)
+}
+
+// gqlUnmarshaler is the unmarshaling of the custom scalars of gqlgen, used for the types not implementing json.Unmarshaler.
+type gqlUnmarshaler interface {
+	UnmarshalGQL(v interface{}) error
+}
+
+var gqlUnmarshalerType = reflect.TypeOf((*gqlUnmarshaler)(nil)).Elem()
+
+// gqlUnmarshalerOf returns the gqlUnmarshaler of v, allocating the nil pointers leading to it.
+func gqlUnmarshalerOf(v reflect.Value) (gqlUnmarshaler, bool) {
+	t := v.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !reflect.PtrTo(t).Implements(gqlUnmarshalerType) || reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil, false
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem())) // v = new(T).
+		}
+		v = v.Elem()
+	}
+
+	return v.Addr().Interface().(gqlUnmarshaler), true
 }
@@ -0,0 +1,312 @@
+package graphqljson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type nameFragment struct {
+	Name string `json:"name" graphql:"name"`
+}
+
+type userFragment struct {
+	ID string `json:"id" graphql:"id"`
+	nameFragment
+}
+
+type OrganizationFragment struct {
+	Plan string `json:"plan" graphql:"plan"`
+}
+
+func TestUnmarshalDataEmbeddedFragments(t *testing.T) {
+	t.Parallel()
+
+	t.Run("embedded struct", func(t *testing.T) {
+		t.Parallel()
+		var got struct {
+			User struct {
+				userFragment
+				Email string `json:"email" graphql:"email"`
+			} `json:"user" graphql:"user"`
+		}
+		err := UnmarshalData([]byte(`{"user":{"id":"1","name":"gopher","email":"gopher@example.com"}}`), &got)
+		require.NoError(t, err)
+		require.Equal(t, "1", got.User.ID)
+		require.Equal(t, "gopher", got.User.Name)
+		require.Equal(t, "gopher@example.com", got.User.Email)
+	})
+
+	t.Run("embedded pointer", func(t *testing.T) {
+		t.Parallel()
+		var got struct {
+			Viewer struct {
+				*OrganizationFragment
+				ID string `json:"id" graphql:"id"`
+			} `json:"viewer" graphql:"viewer"`
+		}
+		err := UnmarshalData([]byte(`{"viewer":{"id":"1","plan":"pro"}}`), &got)
+		require.NoError(t, err)
+		require.Equal(t, "1", got.Viewer.ID)
+		require.NotNil(t, got.Viewer.OrganizationFragment)
+		require.Equal(t, "pro", got.Viewer.Plan)
+	})
+
+	t.Run("embedded in list elements", func(t *testing.T) {
+		t.Parallel()
+		var got struct {
+			Users []struct {
+				userFragment
+			} `json:"users" graphql:"users"`
+		}
+		err := UnmarshalData([]byte(`{"users":[{"id":"1","name":"a"},{"id":"2","name":"b"}]}`), &got)
+		require.NoError(t, err)
+		require.Len(t, got.Users, 2)
+		require.Equal(t, "2", got.Users[1].ID)
+		require.Equal(t, "b", got.Users[1].Name)
+	})
+
+	t.Run("inline fragments", func(t *testing.T) {
+		t.Parallel()
+		var got struct {
+			Search []struct {
+				Typename string `json:"__typename" graphql:"__typename"`
+				User     struct {
+					userFragment
+				} `graphql:"... on User"`
+				Organization OrganizationFragment `graphql:"... on Organization"`
+			} `json:"search" graphql:"search"`
+		}
+		err := UnmarshalData([]byte(`{"search":[{"__typename":"User","id":"1","name":"a"},{"__typename":"Organization","plan":"pro"}]}`), &got)
+		require.NoError(t, err)
+		require.Equal(t, "a", got.Search[0].User.Name)
+		require.Equal(t, "pro", got.Search[1].Organization.Plan)
+	})
+}
+
+func TestUnmarshalDataTypenameOnly(t *testing.T) {
+	t.Parallel()
+	var got struct {
+		Search []*struct {
+			Typename string `json:"__typename" graphql:"__typename"`
+		} `json:"search" graphql:"search"`
+	}
+	err := UnmarshalData([]byte(`{"search":[{"__typename":"User"},{"__typename":"Organization"}]}`), &got)
+	require.NoError(t, err)
+	require.Len(t, got.Search, 2)
+	require.Equal(t, "User", got.Search[0].Typename)
+	require.Equal(t, "Organization", got.Search[1].Typename)
+}
+
+func TestUnmarshalDataNull(t *testing.T) {
+	t.Parallel()
+	got := struct {
+		Viewer *struct {
+			Name string `json:"name" graphql:"name"`
+		} `json:"viewer" graphql:"viewer"`
+		Count int `json:"count" graphql:"count"`
+	}{Count: 1}
+	require.NoError(t, UnmarshalData([]byte(`null`), &got))
+	require.NoError(t, UnmarshalData([]byte(`null`), &got, UseNumber(), DisallowUnknownFields()))
+	require.Nil(t, got.Viewer)
+	require.Equal(t, 1, got.Count)
+}
+
+type rawJSON json.RawMessage
+
+func (r *rawJSON) UnmarshalJSON(data []byte) error {
+	*r = append((*r)[:0], data...)
+
+	return nil
+}
+
+func TestUnmarshalDataWholeValues(t *testing.T) {
+	t.Parallel()
+	var got struct {
+		Settings  rawJSON                `json:"settings" graphql:"settings"`
+		Metadata  *rawJSON               `json:"metadata" graphql:"metadata"`
+		Tags      []rawJSON              `json:"tags" graphql:"tags"`
+		Labels    map[string]interface{} `json:"labels" graphql:"labels"`
+		Extra     interface{}            `json:"extra" graphql:"extra"`
+		Missing   *rawJSON               `json:"missing" graphql:"missing"`
+		AfterName string                 `json:"afterName" graphql:"afterName"`
+	}
+	err := UnmarshalData([]byte(`{
+		"settings":{"theme":{"dark":true,"colors":["red",null,1.5]},"empty":{},"list":[]},
+		"metadata":[1,{"a":"b\"c"}],
+		"tags":[{"x":1},"y",null],
+		"labels":{"team":"core","level":2},
+		"extra":[true],
+		"missing":null,
+		"afterName":"gopher"
+	}`), &got)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"theme":{"dark":true,"colors":["red",null,1.5]},"empty":{},"list":[]}`, string(got.Settings))
+	require.NotNil(t, got.Metadata)
+	require.JSONEq(t, `[1,{"a":"b\"c"}]`, string(*got.Metadata))
+	require.Len(t, got.Tags, 3)
+	require.JSONEq(t, `{"x":1}`, string(got.Tags[0]))
+	require.JSONEq(t, `"y"`, string(got.Tags[1]))
+	require.Equal(t, map[string]interface{}{"team": "core", "level": float64(2)}, got.Labels)
+	require.Equal(t, []interface{}{true}, got.Extra)
+	require.Nil(t, got.Missing)
+	require.Equal(t, "gopher", got.AfterName)
+}
+
+type upperScalar string
+
+func (s *upperScalar) UnmarshalJSON(data []byte) error {
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = upperScalar(strings.ToUpper(v))
+
+	return nil
+}
+
+// gqlScalar only implements the gqlgen unmarshaling of custom scalars
+type gqlScalar struct {
+	value string
+}
+
+func (s *gqlScalar) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("gqlScalar must be a string, got %T", v)
+	}
+	s.value = "gql:" + str
+
+	return nil
+}
+
+func (s gqlScalar) MarshalGQL(w io.Writer) {
+	_, _ = io.WriteString(w, strconv.Quote(s.value))
+}
+
+func TestUnmarshalDataScalarLists(t *testing.T) {
+	t.Parallel()
+	var got struct {
+		Dates       []time.Time   `json:"dates" graphql:"dates"`
+		NullDates   []*time.Time  `json:"nullDates" graphql:"nullDates"`
+		Matrix      [][]time.Time `json:"matrix" graphql:"matrix"`
+		Upper       []upperScalar `json:"upper" graphql:"upper"`
+		GQL         []gqlScalar   `json:"gql" graphql:"gql"`
+		GQLPointers []*gqlScalar  `json:"gqlPointers" graphql:"gqlPointers"`
+		Single      gqlScalar     `json:"single" graphql:"single"`
+		Numbers     []json.Number `json:"numbers" graphql:"numbers"`
+	}
+	err := UnmarshalData([]byte(`{
+		"dates":["2020-01-02T03:04:05Z","2021-01-02T03:04:05Z"],
+		"nullDates":[null,"2020-01-02T03:04:05Z"],
+		"matrix":[["2020-01-02T03:04:05Z"],[]],
+		"upper":["a","b"],
+		"gql":["a","b"],
+		"gqlPointers":["c",null],
+		"single":"d",
+		"numbers":[1,2.5]
+	}`), &got)
+	require.NoError(t, err)
+	date := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.Equal(t, []time.Time{date, date.AddDate(1, 0, 0)}, got.Dates)
+	require.Len(t, got.NullDates, 2)
+	require.Nil(t, got.NullDates[0])
+	require.Equal(t, date, *got.NullDates[1])
+	require.Equal(t, [][]time.Time{{date}, {}}, got.Matrix)
+	require.Equal(t, []upperScalar{"A", "B"}, got.Upper)
+	require.Equal(t, []gqlScalar{{value: "gql:a"}, {value: "gql:b"}}, got.GQL)
+	require.Equal(t, []*gqlScalar{{value: "gql:c"}, nil}, got.GQLPointers)
+	require.Equal(t, gqlScalar{value: "gql:d"}, got.Single)
+	require.Equal(t, []json.Number{"1", "2.5"}, got.Numbers)
+}
+
+func TestUnmarshalDataUseNumber(t *testing.T) {
+	t.Parallel()
+	type result struct {
+		BigInt   interface{}            `json:"bigInt" graphql:"bigInt"`
+		Decimal  json.Number            `json:"decimal" graphql:"decimal"`
+		Settings map[string]interface{} `json:"settings" graphql:"settings"`
+		Amounts  []interface{}          `json:"amounts" graphql:"amounts"`
+	}
+	data := []byte(`{
+		"bigInt":9007199254740993,
+		"decimal":12345678901234567890.123456789,
+		"settings":{"limit":9007199254740993},
+		"amounts":[9007199254740993,0.1]
+	}`)
+
+	var got result
+	require.NoError(t, UnmarshalData(data, &got, UseNumber()))
+	require.Equal(t, result{
+		BigInt:   json.Number("9007199254740993"),
+		Decimal:  json.Number("12345678901234567890.123456789"),
+		Settings: map[string]interface{}{"limit": json.Number("9007199254740993")},
+		Amounts:  []interface{}{json.Number("9007199254740993"), json.Number("0.1")},
+	}, got)
+
+	var lossy result
+	require.NoError(t, UnmarshalData(data, &lossy))
+	require.Equal(t, float64(9007199254740992), lossy.BigInt)
+	require.Equal(t, json.Number("12345678901234567890.123456789"), lossy.Decimal)
+}
+
+type upperEnum string
+
+func (e upperEnum) MarshalGQL(w io.Writer) {
+	_, _ = io.WriteString(w, strconv.Quote(strings.ToUpper(string(e))))
+}
+
+func TestMarshalData(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		data := `{"search":[{"__typename":"User","id":"1","name":"a"},{"__typename":"Organization","plan":"pro"}],"viewer":null,"createdAt":"2020-01-02T03:04:05Z","tags":["a","b"]}`
+		var got struct {
+			Search []struct {
+				Typename string `json:"__typename" graphql:"__typename"`
+				User     struct {
+					userFragment
+				} `graphql:"... on User"`
+				Organization OrganizationFragment `graphql:"... on Organization"`
+			} `json:"search" graphql:"search"`
+			Viewer *struct {
+				*OrganizationFragment
+			} `json:"viewer" graphql:"viewer"`
+			CreatedAt time.Time `json:"createdAt" graphql:"createdAt"`
+			Tags      []string  `json:"tags" graphql:"tags"`
+		}
+		require.NoError(t, UnmarshalData([]byte(data), &got))
+
+		encoded, err := MarshalData(got)
+		require.NoError(t, err)
+		require.Equal(t, data, string(encoded))
+	})
+
+	t.Run("shared fields and aliases", func(t *testing.T) {
+		t.Parallel()
+		type node struct {
+			ID string `json:"id" graphql:"id"`
+		}
+		got := struct {
+			First struct {
+				node
+				Name string `graphql:"label: name"`
+				Role upperEnum
+			} `graphql:"first: user"`
+		}{}
+		got.First.ID = "1"
+		got.First.Name = "a"
+		got.First.Role = "admin"
+
+		encoded, err := MarshalData(&got)
+		require.NoError(t, err)
+		require.Equal(t, `{"first":{"id":"1","label":"a","Role":"ADMIN"}}`, string(encoded))
+	})
+}
@@ -2,6 +2,7 @@ package introspection
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/vektah/gqlparser/v2/ast"
 )
@@ -12,8 +13,21 @@ func ParseIntrospectionQuery(query Query) *ast.SchemaDocument {
 
 	doc.Schema = append(doc.Schema, parseSchemaDefinition(query, typeMap))
 
-	for _, typeVale := range typeMap {
-		doc.Definitions = append(doc.Definitions, parseTypeSystemDefinition(typeVale))
+	// types are added in name order, the generated code does not depend on the order of the map
+	names := make([]string, 0, len(typeMap))
+	for name := range typeMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	declared := make(map[string]bool, len(query.Schema.Directives))
+	for _, directiveValue := range query.Schema.Directives {
+		declared[directiveValue.Name] = true
+	}
+	for _, name := range names {
+		definition := parseTypeSystemDefinition(typeMap[name])
+		definition.Directives = parseTypeDirectives(typeMap[name], declared)
+		parseInputValueDirectives(definition, typeMap[name], declared)
+		doc.Definitions = append(doc.Definitions, definition)
 	}
 
 	for _, directiveValue := range query.Schema.Directives {
@@ -50,6 +64,64 @@ func parseOperationTypeDefinitionForMutation(fullType *FullType) *ast.OperationT
 	return &op
 }
 
+// parseTypeDirectives returns the @specifiedBy and @oneOf directives of a type introspected from LevelOctober2021,
+// when the server declares them
+func parseTypeDirectives(typeVale *FullType, declared map[string]bool) ast.DirectiveList {
+	var directives ast.DirectiveList
+	if typeVale.SpecifiedByURL != nil && declared["specifiedBy"] {
+		directives = append(directives, &ast.Directive{
+			Name: "specifiedBy",
+			Arguments: ast.ArgumentList{{
+				Name:  "url",
+				Value: &ast.Value{Raw: *typeVale.SpecifiedByURL, Kind: ast.StringValue},
+			}},
+		})
+	}
+	if typeVale.IsOneOf != nil && *typeVale.IsOneOf && declared["oneOf"] {
+		directives = append(directives, &ast.Directive{Name: "oneOf"})
+	}
+
+	return directives
+}
+
+// parseInputValueDirectives adds the @deprecated directives of the arguments and input fields of a type
+// introspected from LevelDraft to their definitions, when the server declares it
+func parseInputValueDirectives(definition *ast.Definition, typeVale *FullType, declared map[string]bool) {
+	if !declared["deprecated"] {
+		return
+	}
+
+	switch typeVale.Kind {
+	case TypeKindInputObject:
+		for i, field := range typeVale.InputFields {
+			definition.Fields[i].Directives = deprecatedDirectives(field)
+		}
+	case TypeKindObject, TypeKindInterface:
+		for i, field := range typeVale.Fields {
+			for j, arg := range field.Args {
+				definition.Fields[i].Arguments[j].Directives = deprecatedDirectives(arg)
+			}
+		}
+	}
+}
+
+// deprecatedDirectives returns the @deprecated directive of a deprecated input value, with its reason if any
+func deprecatedDirectives(input *InputValue) ast.DirectiveList {
+	if !input.IsDeprecated {
+		return nil
+	}
+
+	directive := &ast.Directive{Name: "deprecated"}
+	if input.DeprecationReason != nil {
+		directive.Arguments = ast.ArgumentList{{
+			Name:  "reason",
+			Value: &ast.Value{Raw: *input.DeprecationReason, Kind: ast.StringValue},
+		}}
+	}
+
+	return ast.DirectiveList{directive}
+}
+
 func parseDirectiveDefinition(directiveValue *DirectiveType) *ast.DirectiveDefinition {
 	args := make(ast.ArgumentDefinitionList, 0, len(directiveValue.Args))
 	for _, arg := range directiveValue.Args {
@@ -1,5 +1,70 @@
 package introspection
 
+import (
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// Level is the introspection capability of a server, selecting the fields requested by the introspection query
+type Level string
+
+const (
+	// LevelJune2018 requests the fields of the June 2018 specification, which every server supports, the default
+	LevelJune2018 Level = "june2018"
+	// LevelOctober2021 also requests the specifiedByURL of the scalars
+	LevelOctober2021 Level = "october2021"
+	// LevelDraft also requests the deprecated arguments and input fields and whether the input types are @oneOf
+	LevelDraft Level = "draft"
+)
+
+// Levels are the known introspection levels, from the oldest
+var Levels = []Level{LevelJune2018, LevelOctober2021, LevelDraft}
+
+// ErrUnknownLevel is returned by QueryForLevel for a level missing from Levels
+var ErrUnknownLevel = xerrors.New("unknown introspection level")
+
+// QueryForLevel returns the introspection query of the level, Introspection for LevelJune2018 and the empty level
+func QueryForLevel(level Level) (string, error) {
+	query, ok := queries[level]
+	if !ok {
+		return "", xerrors.Errorf("%q: %w", level, ErrUnknownLevel)
+	}
+
+	return query, nil
+}
+
+// IsIntrospection reports whether query is the introspection query of a level
+func IsIntrospection(query string) bool {
+	for _, q := range queries {
+		if query == q {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	october2021Introspection = strings.Replace(Introspection,
+		"      description\n      fields(", "      description\n      specifiedByURL\n      fields(", 1)
+	draftIntrospection = strings.NewReplacer(
+		"      specifiedByURL\n", "      specifiedByURL\n      isOneOf\n",
+		"args {", "args(includeDeprecated: true) {",
+		"inputFields {", "inputFields(includeDeprecated: true) {",
+		"      defaultValue\n", "      defaultValue\n      isDeprecated\n      deprecationReason\n",
+	).Replace(october2021Introspection)
+)
+
+// queries are the introspection queries by level
+var queries = map[Level]string{
+	"":               Introspection,
+	LevelJune2018:    Introspection,
+	LevelOctober2021: october2021Introspection,
+	LevelDraft:       draftIntrospection,
+}
+
+// Introspection is the introspection query of LevelJune2018
 const Introspection = `query Query {
       __schema {
         queryType { name }
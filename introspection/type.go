@@ -28,6 +28,10 @@ type FullType struct {
 	Kind        TypeKind
 	Name        *string
 	Description *string
+	// SpecifiedByURL is only introspected with LevelOctober2021 and LevelDraft
+	SpecifiedByURL *string
+	// IsOneOf is only introspected with LevelDraft
+	IsOneOf     *bool
 	Fields      []*FieldValue
 	InputFields []*InputValue
 	Interfaces  []*TypeRef
@@ -54,6 +58,9 @@ type InputValue struct {
 	Description  *string
 	Type         TypeRef
 	DefaultValue *string
+	// IsDeprecated and DeprecationReason are only introspected with LevelDraft
+	IsDeprecated      bool
+	DeprecationReason *string
 }
 
 type TypeRef struct {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 
@@ -12,14 +13,36 @@ import (
 )
 
 func main() {
+	check := flag.Bool("check", false, "report whether the generated client is out of date with the schema, without regenerating it")
+	refresh := flag.Bool("refresh", false, "introspect the endpoint even when the introspection file exists")
+	flag.Parse()
+
 	ctx := context.Background()
 	cfg, err := config.LoadConfigFromDefaultLocations()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%+v", err.Error())
 		os.Exit(2)
 	}
+	if *refresh && cfg.Endpoint != nil {
+		cfg.Endpoint.RefreshIntrospection = true
+	}
 
 	clientPlugin := clientgen.New(cfg.Query, cfg.Client, cfg.Generate)
+	if *check {
+		drift, err := generator.CheckSchemaDrift(ctx, cfg, api.AddPlugin(clientPlugin))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%+v", err.Error())
+			os.Exit(4)
+		}
+
+		if drift {
+			fmt.Fprintln(os.Stderr, "the generated client is out of date with the schema, run gqlgenc to regenerate it")
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	if err := generator.Generate(ctx, cfg, api.AddPlugin(clientPlugin)); err != nil {
 		fmt.Fprintf(os.Stderr, "%+v", err.Error())
 		os.Exit(4)